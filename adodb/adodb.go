@@ -0,0 +1,143 @@
+//go:build windows
+
+// Package adodb provides typed wrappers over ADODB.Connection and
+// ADODB.Recordset for database access through OLE DB providers, built on
+// sugar.Chain in the same way as the excel package.
+package adodb
+
+import (
+	"fmt"
+
+	"github.com/xll-gen/sugar"
+)
+
+// Connection represents an ADODB.Connection object.
+type Connection interface {
+	sugar.Chain
+	// Open opens the connection described by connStr, such as
+	// "Provider=MSOLEDBSQL;Server=.;Database=db;Trusted_Connection=yes".
+	Open(connStr string) error
+	// Execute runs a statement and returns its result set. For statements
+	// that return no rows the Recordset is closed.
+	Execute(sql string) Recordset
+	// Close closes the connection.
+	Close() error
+}
+
+type connection struct {
+	sugar.Chain
+}
+
+// NewConnection creates a new, closed connection.
+func NewConnection(ctx sugar.Context) Connection {
+	return &connection{ctx.Create("ADODB.Connection")}
+}
+
+func (c *connection) Open(connStr string) error {
+	return c.Call("Open", connStr).Err()
+}
+
+func (c *connection) Execute(sql string) Recordset {
+	return &recordset{c.Call("Execute", sql)}
+}
+
+func (c *connection) Close() error {
+	return c.Call("Close").Err()
+}
+
+// Recordset represents an ADODB.Recordset object. A Recordset has a current
+// row, moved with MoveFirst and MoveNext, whose values are read through its
+// Fields.
+type Recordset interface {
+	sugar.Chain
+	// EOF reports whether the current position is past the last row.
+	EOF() (bool, error)
+	// MoveFirst moves to the first row.
+	MoveFirst() error
+	// MoveNext moves to the next row.
+	MoveNext() error
+	// FieldNames returns the names of the columns.
+	FieldNames() ([]string, error)
+	// Rows reads the remaining rows, from the current position to the end,
+	// into maps keyed by field name.
+	Rows() ([]map[string]interface{}, error)
+	// Close closes the recordset.
+	Close() error
+}
+
+type recordset struct {
+	sugar.Chain
+}
+
+// NewRecordset creates a new, closed recordset. With fields appended through
+// Fields.Append, it can be opened without a connection as an in-memory
+// table.
+func NewRecordset(ctx sugar.Context) Recordset {
+	return &recordset{ctx.Create("ADODB.Recordset")}
+}
+
+func (r *recordset) EOF() (bool, error) {
+	return r.Get("EOF").GetBool()
+}
+
+func (r *recordset) MoveFirst() error {
+	return r.Call("MoveFirst").Err()
+}
+
+func (r *recordset) MoveNext() error {
+	return r.Call("MoveNext").Err()
+}
+
+func (r *recordset) FieldNames() ([]string, error) {
+	var names []string
+	err := r.Get("Fields").ForEach(func(field sugar.Chain) error {
+		name, err := field.Get("Name").GetString()
+		names = append(names, name)
+		return err
+	}).Err()
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+func (r *recordset) Rows() ([]map[string]interface{}, error) {
+	// Field objects follow the current row, so they are fetched once.
+	fields, err := r.Get("Fields").ToSlice()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		if names[i], err = field.Get("Name").GetString(); err != nil {
+			return nil, err
+		}
+	}
+
+	var rows []map[string]interface{}
+	for {
+		eof, err := r.EOF()
+		if err != nil {
+			return rows, err
+		}
+		if eof {
+			return rows, nil
+		}
+		row := make(map[string]interface{}, len(fields))
+		for i, field := range fields {
+			v, err := field.Get("Value").Value()
+			if err != nil {
+				return rows, fmt.Errorf("row %d, field %s: %w", len(rows), names[i], err)
+			}
+			row[names[i]] = v
+		}
+		rows = append(rows, row)
+		if err := r.MoveNext(); err != nil {
+			return rows, err
+		}
+	}
+}
+
+func (r *recordset) Close() error {
+	return r.Call("Close").Err()
+}
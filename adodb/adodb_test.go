@@ -0,0 +1,66 @@
+//go:build windows
+
+package adodb_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xll-gen/sugar"
+	"github.com/xll-gen/sugar/adodb"
+)
+
+// ADO field types.
+const (
+	adInteger  = 3
+	adVarWChar = 202
+)
+
+func TestRecordset_Rows(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		rs := adodb.NewRecordset(ctx)
+		if err := rs.Err(); err != nil {
+			t.Skip("ADODB not available:", err)
+			return nil
+		}
+
+		fields := rs.Get("Fields")
+		fields.Call("Append", "ID", adInteger)
+		fields.Call("Append", "Name", adVarWChar, 50)
+		if err := rs.Call("Open").Err(); err != nil {
+			t.Fatalf("opening in-memory recordset failed: %v", err)
+		}
+		defer rs.Close()
+
+		for i, name := range []string{"alpha", "beta"} {
+			rs.Call("AddNew")
+			fields.Get("Item", "ID").Put("Value", i+1)
+			fields.Get("Item", "Name").Put("Value", name)
+			rs.Call("Update")
+		}
+		if err := rs.MoveFirst(); err != nil {
+			t.Fatalf("MoveFirst failed: %v", err)
+		}
+
+		names, err := rs.FieldNames()
+		if err != nil || !reflect.DeepEqual(names, []string{"ID", "Name"}) {
+			t.Errorf("expected [ID Name], got %v (%v)", names, err)
+		}
+
+		rows, err := rs.Rows()
+		if err != nil {
+			t.Fatalf("Rows failed: %v", err)
+		}
+		want := []map[string]interface{}{
+			{"ID": int32(1), "Name": "alpha"},
+			{"ID": int32(2), "Name": "beta"},
+		}
+		if !reflect.DeepEqual(rows, want) {
+			t.Errorf("expected %v, got %v", want, rows)
+		}
+		if eof, _ := rs.EOF(); !eof {
+			t.Error("expected the recordset to be at EOF after Rows")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,69 @@
+//go:build windows
+
+package sugar
+
+import (
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"github.com/go-ole/go-ole"
+)
+
+var (
+	modole32 = syscall.NewLazyDLL("ole32.dll")
+
+	procCoIncrementMTAUsage = modole32.NewProc("CoIncrementMTAUsage")
+	procCoDecrementMTAUsage = modole32.NewProc("CoDecrementMTAUsage")
+	procCoGetApartmentType  = modole32.NewProc("CoGetApartmentType")
+)
+
+// ApartmentModel selects the COM apartment a Runner enters.
+type ApartmentModel int
+
+const (
+	// STA runs the function on a locked OS thread in a single-threaded
+	// apartment. It is the default, and what most automation servers expect.
+	STA ApartmentModel = iota
+	// MTA joins the multithreaded apartment. The goroutine is not locked to an
+	// OS thread, so it suits free-threaded servers and headless use. Chains
+	// created in the MTA are not bound to the thread they were created on.
+	MTA
+)
+
+// WithApartment selects the apartment model for Do and Go. Nested calls keep
+// the apartment of the outermost Do.
+func (r *Runner) WithApartment(model ApartmentModel) *Runner {
+	r.apartment = model
+	return r
+}
+
+// enter initializes COM for the apartment model and returns the function that
+// undoes it.
+func (m ApartmentModel) enter() (func(), error) {
+	if m == MTA && procCoIncrementMTAUsage.Find() == nil {
+		// Keeps the MTA alive without tying it to this thread, so the goroutine
+		// is free to migrate; every thread not in an STA belongs to it.
+		var cookie uintptr
+		if hr, _, _ := procCoIncrementMTAUsage.Call(uintptr(unsafe.Pointer(&cookie))); hr != 0 {
+			return nil, ole.NewError(hr)
+		}
+		return func() { procCoDecrementMTAUsage.Call(cookie) }, nil
+	}
+
+	// CoUninitialize must run on the thread that called CoInitializeEx, so
+	// the thread stays locked, also for MTA before Windows 8.
+	coinit := uint32(ole.COINIT_APARTMENTTHREADED)
+	if m == MTA {
+		coinit = ole.COINIT_MULTITHREADED
+	}
+	runtime.LockOSThread()
+	if err := ole.CoInitializeEx(0, coinit); err != nil {
+		runtime.UnlockOSThread()
+		return nil, err
+	}
+	return func() {
+		ole.CoUninitialize()
+		runtime.UnlockOSThread()
+	}, nil
+}
@@ -0,0 +1,61 @@
+//go:build windows
+
+package sugar
+
+import (
+	"fmt"
+
+	"github.com/go-ole/go-ole"
+)
+
+// ParseCLSID parses a GUID such as "{00024500-0000-0000-C000-000000000046}".
+// The braces are optional.
+func ParseCLSID(s string) (*ole.GUID, error) {
+	guid := ole.NewGUID(s)
+	if guid == nil {
+		return nil, fmt.Errorf("invalid CLSID %q", s)
+	}
+	return guid, nil
+}
+
+// CreateCLSID starts a new chain by creating a new COM object from the given
+// CLSID, bypassing ProgID resolution. This is useful for components that are
+// registered without a ProgID, or whose ProgID maps to different classes in
+// 32-bit and 64-bit registrations.
+func CreateCLSID(clsid *ole.GUID) Chain {
+	unknown, err := ole.CreateInstance(clsid, ole.IID_IUnknown)
+	if err != nil {
+		return &chain{err: err}
+	}
+
+	disp, err := unknown.QueryInterface(ole.IID_IDispatch)
+	unknown.Release()
+	if err != nil {
+		return &chain{err: err}
+	}
+
+	return &chain{
+		disp:   disp,
+		thread: ownerThread(),
+	}
+}
+
+// GetActiveCLSID starts a new chain by attaching to a running COM object
+// registered in the Running Object Table under the given CLSID.
+func GetActiveCLSID(clsid *ole.GUID) Chain {
+	unknown, err := ole.GetActiveObject(clsid, ole.IID_IUnknown)
+	if err != nil {
+		return &chain{err: err}
+	}
+
+	disp, err := unknown.QueryInterface(ole.IID_IDispatch)
+	unknown.Release()
+	if err != nil {
+		return &chain{err: err}
+	}
+
+	return &chain{
+		disp:   disp,
+		thread: ownerThread(),
+	}
+}
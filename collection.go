@@ -0,0 +1,381 @@
+//go:build windows
+
+package sugar
+
+import (
+	"errors"
+	"unsafe"
+
+	"github.com/go-ole/go-ole"
+	"github.com/go-ole/go-ole/oleutil"
+)
+
+var iidEnumVariant = ole.NewGUID("{00020404-0000-0000-C000-000000000046}")
+
+// enumerate walks the object's _NewEnum enumerator and calls fn for each item.
+// The item VARIANT is cleared after fn returns; iteration stops at the first
+// error returned by fn, or before the next item once the Context is done.
+func (c *chain) enumerate(fn func(itemVar *ole.VARIANT) error) error {
+	enumVar, err := oleutil.GetProperty(c.disp, "_NewEnum")
+	if err != nil {
+		return err
+	}
+	defer enumVar.Clear()
+
+	var unknown *ole.IUnknown
+	switch enumVar.VT {
+	case ole.VT_UNKNOWN:
+		unknown = enumVar.ToIUnknown()
+	case ole.VT_DISPATCH:
+		unknown = &enumVar.ToIDispatch().IUnknown
+	default:
+		return errors.New("_NewEnum is not object")
+	}
+	if unknown == nil {
+		return errors.New("_NewEnum nil")
+	}
+
+	enumRaw, err := unknown.QueryInterface(iidEnumVariant)
+	if err != nil {
+		return err
+	}
+	defer enumRaw.Release()
+
+	enum := (*ole.IEnumVARIANT)(unsafe.Pointer(enumRaw))
+
+	for {
+		if err := c.ctxErr(); err != nil {
+			return err
+		}
+		itemVar, fetched, err := enum.Next(1)
+		if err != nil || fetched == 0 {
+			return nil
+		}
+		err = fn(&itemVar)
+		itemVar.Clear()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// errStopIteration ends an enumeration on behalf of All2.
+var errStopIteration = errors.New("stop iteration")
+
+// All returns an iterator over the items of a collection.
+func (c *chain) All() func(yield func(item Chain) bool) {
+	return func(yield func(item Chain) bool) {
+		c.All2()(func(_ int, item Chain) bool {
+			return yield(item)
+		})
+	}
+}
+
+// All2 returns an iterator over the positions and items of a collection.
+func (c *chain) All2() func(yield func(i int, item Chain) bool) {
+	return func(yield func(i int, item Chain) bool) {
+		if c.err != nil {
+			yield(0, c)
+			return
+		}
+		if c.disp == nil {
+			yield(0, &chain{err: errors.New("dispatch is nil"), ctx: c.ctx})
+			return
+		}
+
+		i := 0
+		err := c.enumerate(func(itemVar *ole.VARIANT) error {
+			if itemVar.VT != ole.VT_DISPATCH {
+				return nil
+			}
+			item := c.newItem(itemVar)
+			more := yield(i, item)
+			i++
+			if c.ctx == nil {
+				item.Release()
+			}
+			if !more {
+				return errStopIteration
+			}
+			return nil
+		})
+		if err != nil && err != errStopIteration {
+			yield(i, &chain{err: err, ctx: c.ctx})
+		}
+	}
+}
+
+// ctxErr returns the error of the chain's Context, if it has one and it is
+// done.
+func (c *chain) ctxErr() error {
+	if c.ctx == nil {
+		return nil
+	}
+	return c.ctx.Err()
+}
+
+// newItem wraps a VT_DISPATCH enumeration item in a chain holding its own
+// reference, tracked by the Context if one is present.
+func (c *chain) newItem(itemVar *ole.VARIANT) *chain {
+	itemChain := c.wrapItem(itemVar)
+	if c.ctx != nil {
+		c.ctx.Track(itemChain)
+	}
+	return itemChain
+}
+
+// wrapItem is like newItem but leaves the item untracked, so that it can be
+// released early. Chains derived from the item are still tracked.
+func (c *chain) wrapItem(itemVar *ole.VARIANT) *chain {
+	itemDisp := itemVar.ToIDispatch()
+	itemDisp.AddRef()
+
+	return &chain{
+		disp:   itemDisp,
+		ctx:    c.ctx,
+		thread: c.thread,
+	}
+}
+
+// ToSlice materializes all items of a collection.
+//
+// Every returned chain keeps its item alive: with a Context they are released
+// when the Context is, otherwise the caller must release each of them. For
+// large collections this holds one reference per item at once.
+func (c *chain) ToSlice() ([]Chain, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.disp == nil {
+		return nil, errors.New("dispatch is nil")
+	}
+
+	var items []Chain
+	err := c.enumerate(func(itemVar *ole.VARIANT) error {
+		if itemVar.VT == ole.VT_DISPATCH {
+			items = append(items, c.newItem(itemVar))
+		}
+		return nil
+	})
+	if err != nil {
+		if c.ctx == nil {
+			for _, item := range items {
+				item.Release()
+			}
+		}
+		return nil, err
+	}
+	return items, nil
+}
+
+// ForEachSnapshot captures every item of a collection before invoking the
+// callback, so the callback may add or delete items of the underlying
+// collection without disturbing the enumeration. Each item is released once
+// its callback returns. Errors are handled as in ForEach.
+func (c *chain) ForEachSnapshot(callback func(item Chain) error) Chain {
+	if c.err != nil || c.disp == nil {
+		return c
+	}
+
+	var items []*chain
+	err := c.enumerate(func(itemVar *ole.VARIANT) error {
+		if itemVar.VT == ole.VT_DISPATCH {
+			items = append(items, c.wrapItem(itemVar))
+		}
+		return nil
+	})
+	if err != nil {
+		for _, item := range items {
+			item.Release()
+		}
+		return &chain{err: err, ctx: c.ctx}
+	}
+
+	for i, item := range items {
+		cbErr := c.ctxErr()
+		if cbErr == nil {
+			cbErr = callback(item)
+		}
+		item.Release()
+		if cbErr != nil {
+			for _, rest := range items[i+1:] {
+				rest.Release()
+			}
+			return &chain{err: cbErr, ctx: c.ctx}
+		}
+	}
+	return c
+}
+
+// Map collects the values returned by fn for each item of a collection.
+func (c *chain) Map(fn func(item Chain) (interface{}, error)) ([]interface{}, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.disp == nil {
+		return nil, errors.New("dispatch is nil")
+	}
+
+	var values []interface{}
+	err := c.ForEach(func(item Chain) error {
+		v, err := fn(item)
+		if err != nil {
+			return err
+		}
+		values = append(values, v)
+		return nil
+	}).Err()
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Filter returns the items of a collection for which pred returns true.
+// Matched items stay referenced (and tracked by the Context, if any), while
+// the others are released as soon as pred has run.
+func (c *chain) Filter(pred func(item Chain) (bool, error)) ([]Chain, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.disp == nil {
+		return nil, errors.New("dispatch is nil")
+	}
+
+	var matched []Chain
+	err := c.enumerate(func(itemVar *ole.VARIANT) error {
+		if itemVar.VT != ole.VT_DISPATCH {
+			return nil
+		}
+		item := c.wrapItem(itemVar)
+		ok, err := pred(item)
+		if err != nil || !ok {
+			item.Release()
+			return err
+		}
+		if c.ctx != nil {
+			c.ctx.Track(item)
+		}
+		matched = append(matched, item)
+		return nil
+	})
+	if err != nil {
+		if c.ctx == nil {
+			for _, item := range matched {
+				item.Release()
+			}
+		}
+		return nil, err
+	}
+	return matched, nil
+}
+
+// Find returns the first item of a collection for which pred returns true,
+// stopping the enumeration there. Items that do not match are released
+// immediately. If nothing matches, it returns a nil Chain and ErrNotFound.
+func (c *chain) Find(pred func(item Chain) (bool, error)) (Chain, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.disp == nil {
+		return nil, errors.New("dispatch is nil")
+	}
+
+	var found *chain
+	err := c.enumerate(func(itemVar *ole.VARIANT) error {
+		if itemVar.VT != ole.VT_DISPATCH {
+			return nil
+		}
+		item := c.wrapItem(itemVar)
+		ok, err := pred(item)
+		if err != nil || !ok {
+			item.Release()
+			return err
+		}
+		found = item
+		return ErrForEachBreak
+	})
+	if found != nil {
+		if c.ctx != nil {
+			c.ctx.Track(found)
+		}
+		return found, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, ErrNotFound
+}
+
+// Map applies fn to each item of coll and collects the typed results,
+// stopping at the first error. Each item is released as soon as fn returns.
+func Map[T any](coll Chain, fn func(item Chain) (T, error)) ([]T, error) {
+	if err := coll.Err(); err != nil {
+		return nil, err
+	}
+
+	var results []T
+	err := coll.ForEach(func(item Chain) error {
+		v, err := fn(item)
+		// ForEach releases items that no Context tracks itself. Tracked items
+		// are untracked as they are released, so that the Context does not
+		// release them a second time.
+		if impl, ok := item.(*chain); ok && impl.ctx != nil {
+			impl.ctx.ReleaseChain(item)
+		}
+		if err != nil {
+			return err
+		}
+		results = append(results, v)
+		return nil
+	}).Err()
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// CollectProperties reads the named properties of every item of coll, returning
+// one map per item keyed by property name. It stops at the first error.
+func CollectProperties(coll Chain, props []string) ([]map[string]interface{}, error) {
+	return Map(coll, func(item Chain) (map[string]interface{}, error) {
+		row := make(map[string]interface{}, len(props))
+		for _, prop := range props {
+			v, err := item.Get(prop).Value()
+			if err != nil {
+				return nil, err
+			}
+			row[prop] = v
+		}
+		return row, nil
+	})
+}
+
+// notFoundCodes are the codes collections report for a missing item.
+var notFoundCodes = map[uint32]bool{
+	0x8002000B:          true, // DISP_E_BADINDEX
+	dispEMemberNotFound: true,
+	0x800A03EC:          true, // Excel's "subscript out of range"
+}
+
+// GetOrCreate returns the item of coll with the given key, or the object made
+// by create if there is no such item or the lookup yields Nothing. It
+// captures the idempotent "get the sheet named X, or add it" pattern. Other
+// lookup failures, such as a cancelled Context or a lost connection, are
+// returned without calling create.
+func GetOrCreate(coll Chain, key interface{}, create func(coll Chain) Chain) Chain {
+	if coll.Err() != nil {
+		return coll
+	}
+	item := coll.Index(key)
+	if err := item.Err(); err != nil {
+		if code, ok := ExceptionCode(err); !ok || !notFoundCodes[code] {
+			return item
+		}
+		return create(coll)
+	}
+	if impl, ok := item.(*chain); ok && impl.disp == nil {
+		return create(coll)
+	}
+	return item
+}
@@ -0,0 +1,154 @@
+//go:build windows
+
+package sugar
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-ole/go-ole"
+)
+
+// Column is one column of a two-dimensional result with its values gathered
+// into a typed slice.
+type Column struct {
+	// Index is the 0-based position of the column.
+	Index int
+	// Name is the header of the column, when the grid had a header row.
+	Name string
+	// Values is a []int64, []float64, []string, []bool or []time.Time when
+	// every non-nil cell of the column has that type, numbers being integers
+	// only if all of them are integral, and a []interface{} otherwise. In typed
+	// slices, nil cells become the zero value.
+	Values interface{}
+}
+
+// CallColumns calls a method returning a two-dimensional array, such as a
+// query result, and splits it into typed columns (see Columns).
+func (c *chain) CallColumns(method string, params ...interface{}) ([]Column, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.disp == nil {
+		return nil, errors.New("dispatch is nil")
+	}
+	result, err := c.invoke(OpCall, method, ole.DISPATCH_METHOD, params)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Clear()
+	if result.VT&ole.VT_ARRAY == 0 {
+		return nil, fmt.Errorf("%s did not return an array", method)
+	}
+
+	grid, err := decodeGrid(result, c.options().errorsAsErrors)
+	if err != nil {
+		return nil, err
+	}
+	return Columns(grid, false), nil
+}
+
+// Columns splits a rectangular grid into typed columns. With header, the first
+// row supplies the column names and is not part of the values.
+func Columns(grid [][]interface{}, header bool) []Column {
+	if len(grid) == 0 {
+		return nil
+	}
+	var names []interface{}
+	if header {
+		names, grid = grid[0], grid[1:]
+	}
+
+	cols := make([]Column, len(names))
+	if !header && len(grid) > 0 {
+		cols = make([]Column, len(grid[0]))
+	}
+	for i := range cols {
+		cols[i].Index = i
+		if header && names[i] != nil {
+			cols[i].Name = fmt.Sprint(names[i])
+		}
+		cells := make([]interface{}, len(grid))
+		for r, row := range grid {
+			if i < len(row) {
+				cells[r] = row[i]
+			}
+		}
+		cols[i].Values = typedColumn(cells)
+	}
+	return cols
+}
+
+// typedColumn converts cells to the narrowest slice type holding all of them.
+func typedColumn(cells []interface{}) interface{} {
+	var kind string
+	integral := true
+	for _, cell := range cells {
+		var k string
+		switch v := cell.(type) {
+		case nil:
+			continue
+		case string:
+			k = "string"
+		case bool:
+			k = "bool"
+		case time.Time:
+			k = "time"
+		case float64:
+			k = "number"
+			integral = integral && v == math.Trunc(v) && math.Abs(v) < 1<<53
+		case float32:
+			k = "number"
+			integral = integral && float64(v) == math.Trunc(float64(v))
+		case int, int8, int16, int32, int64, uint8, uint16, uint32:
+			k = "number"
+		default:
+			return cells
+		}
+		if kind != "" && kind != k {
+			return cells
+		}
+		kind = k
+	}
+
+	switch kind {
+	case "string":
+		out := make([]string, len(cells))
+		for i, cell := range cells {
+			out[i], _ = cell.(string)
+		}
+		return out
+	case "bool":
+		out := make([]bool, len(cells))
+		for i, cell := range cells {
+			out[i], _ = cell.(bool)
+		}
+		return out
+	case "time":
+		out := make([]time.Time, len(cells))
+		for i, cell := range cells {
+			out[i], _ = cell.(time.Time)
+		}
+		return out
+	case "number":
+		if integral {
+			out := make([]int64, len(cells))
+			for i, cell := range cells {
+				if cell != nil {
+					out[i], _ = toInt64(cell)
+				}
+			}
+			return out
+		}
+		out := make([]float64, len(cells))
+		for i, cell := range cells {
+			if cell != nil {
+				out[i], _ = toFloat64(cell)
+			}
+		}
+		return out
+	}
+	return cells
+}
@@ -4,6 +4,10 @@ package sugar
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
 
 	"github.com/go-ole/go-ole"
 )
@@ -11,7 +15,37 @@ import (
 type sugarCtxKey struct{}
 var activeSugarKey = sugarCtxKey{}
 
+type currentCtxKey struct{}
+
+// ContextOption configures the behavior of a Context created by NewContext.
+type ContextOption func(*contextOptions)
+
+type contextOptions struct {
+	errorsAsErrors bool
+	dispids        *dispidCache
+	pump           bool
+	checkThreads   bool
+	suggest        bool
+	// apartment is the apartment of the outermost Do.
+	apartment ApartmentModel
+}
+
+// ErrorsAsErrors makes Value return a *CellError when the result is a VT_ERROR
+// VARIANT (for example an Excel cell containing #N/A) instead of silently
+// returning the decoded value.
+func ErrorsAsErrors() ContextOption {
+	return func(o *contextOptions) {
+		o.errorsAsErrors = true
+	}
+}
+
 // Context manages the lifecycle of multiple Chains and implements context.Context.
+//
+// Once a Context is canceled or its deadline passes, chains tracked by it stop
+// making COM calls: Get, Call, Put and the other operations fail with an error
+// wrapping context.Canceled or context.DeadlineExceeded, and ForEach, ToSlice
+// and similar stop before the next item. A COM call already in progress cannot
+// be interrupted; cancellation takes effect at the next call.
 type Context interface {
 	context.Context
 	// Track registers a Chain with the Context for automatic release.
@@ -20,38 +54,130 @@ type Context interface {
 	Create(progID string) Chain
 	// GetActive is a wrapper around sugar.GetActive that automatically tracks the chain.
 	GetActive(progID string) Chain
+	// GetActiveByPID is a wrapper around sugar.GetActiveByPID that automatically tracks the chain.
+	GetActiveByPID(progID string, pid int) Chain
+	// CreateCLSID is a wrapper around sugar.CreateCLSID that automatically tracks the chain.
+	CreateCLSID(clsid *ole.GUID) Chain
+	// GetActiveCLSID is a wrapper around sugar.GetActiveCLSID that automatically tracks the chain.
+	GetActiveCLSID(clsid *ole.GUID) Chain
 	// From is a wrapper around sugar.From that automatically tracks the chain.
 	From(disp *ole.IDispatch) Chain
+	// FromDispatcher is a wrapper around sugar.FromDispatcher that automatically tracks the chain.
+	FromDispatcher(d Dispatcher) Chain
 	// Release releases all tracked chains in LIFO order.
 	Release() error
+	// Detach stops tracking ch, so that it is no longer released by Release or
+	// Rollback and its lifetime belongs to the caller. Chains derived from it
+	// later are still tracked. Detaching an untracked chain is a no-op.
+	Detach(ch Chain) Chain
+	// ReleaseChain releases ch right away and stops tracking it, so that it is
+	// not released again when the Context is. It returns an error if ch is not
+	// tracked by this Context.
+	ReleaseChain(ch Chain) error
+	// Len returns the number of chains currently tracked.
+	Len() int
+	// Stats returns a breakdown of the tracked chains, for debugging reference
+	// leaks.
+	Stats() ContextStats
+	// Mark returns a mark identifying the chains tracked from now on, for use
+	// with Rollback. Detaching or releasing chains in the meantime does not
+	// move it.
+	Mark() int
+	// Rollback releases, in LIFO order, every chain tracked after the given mark
+	// and still tracked, and stops tracking them.
+	Rollback(mark int) error
+	// Transaction runs fn and, if it returns an error or panics, releases every
+	// chain tracked while it ran (see Mark and Rollback). It only undoes the
+	// creation of objects: changes fn made through them, such as cell writes,
+	// stay in place.
+	Transaction(fn func(ctx Context) error) error
 	// Do executes the function within a nested scope of this context.
 	Do(fn func(ctx Context) error) error
 	// Go executes the function in a new goroutine branching from this context.
 	Go(fn func(ctx Context) error)
+	// GoResult is like Go but returns a channel delivering the function's
+	// error once the goroutine has finished.
+	GoResult(fn func(ctx Context) error) <-chan error
 }
 
+// ContextStats describes the chains tracked by a Context.
+type ContextStats struct {
+	// Tracked is the number of tracked chains, as returned by Len.
+	Tracked int
+	// Roots counts chains that were tracked directly, such as those from
+	// Create, GetActive or From.
+	Roots int
+	// Derived counts chains obtained through another tracked chain, such as
+	// the results of Get, Call, Fork or ForEach.
+	Derived int
+	// Pending counts chains whose ReleaseAfter deadline has passed and that
+	// are waiting to be released.
+	Pending int
+}
+
+// sugarContext guards its bookkeeping with mu, so a Context may be shared
+// between goroutines. The COM objects it tracks remain bound to the apartment
+// that created them; the lock only keeps the chain list consistent.
 type sugarContext struct {
 	context.Context
-	chains []Chain
+	mu     sync.Mutex
+	chains []trackedChain
+	// seq is the sequence number of the next tracked chain.
+	seq int
+	// pending holds chains whose ReleaseAfter deadline has passed, waiting to
+	// be released on the thread that uses this Context.
+	pending []Chain
+	// observers holds the properties watched with Observe, polled by Wait.
+	observers []*observer
+	opts      contextOptions
+}
+
+// trackedChain is a chain tracked by a Context and the order it was tracked
+// in, which Mark and Rollback rely on.
+type trackedChain struct {
+	Chain
+	seq int
 }
 
 // NewContext creates a new Context with the given parent.
-func NewContext(parent context.Context) Context {
+// Options are inherited from the nearest enclosing Context, if any, and the
+// given options are applied on top of them.
+func NewContext(parent context.Context, opts ...ContextOption) Context {
 	if parent == nil {
 		parent = context.Background()
 	}
-	return &sugarContext{
+	c := &sugarContext{
 		Context: parent,
-		chains:  make([]Chain, 0, 4),
+		chains:  make([]trackedChain, 0, 4),
+	}
+	if outer, ok := parent.Value(currentCtxKey{}).(*sugarContext); ok {
+		c.opts = outer.opts
+	}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	return c
+}
+
+// Value returns the Context itself for the internal lookup key and defers
+// to the parent context otherwise.
+func (c *sugarContext) Value(key interface{}) interface{} {
+	if key == (currentCtxKey{}) {
+		return c
 	}
+	return c.Context.Value(key)
 }
 
 // Track registers a Chain with the Context for automatic release.
 func (c *sugarContext) Track(ch Chain) Chain {
 	if impl, ok := ch.(*chain); ok {
+		impl.root = impl.ctx == nil
 		impl.ctx = c
 	}
-	c.chains = append(c.chains, ch)
+	c.mu.Lock()
+	c.chains = append(c.chains, trackedChain{Chain: ch, seq: c.seq})
+	c.seq++
+	c.mu.Unlock()
 	return ch
 }
 
@@ -65,26 +191,224 @@ func (c *sugarContext) GetActive(progID string) Chain {
 	return c.Track(GetActive(progID))
 }
 
+// GetActiveByPID is a wrapper around sugar.GetActiveByPID that automatically tracks the chain.
+func (c *sugarContext) GetActiveByPID(progID string, pid int) Chain {
+	return c.Track(GetActiveByPID(progID, pid))
+}
+
+// CreateCLSID is a wrapper around sugar.CreateCLSID that automatically tracks the chain.
+func (c *sugarContext) CreateCLSID(clsid *ole.GUID) Chain {
+	return c.Track(CreateCLSID(clsid))
+}
+
+// GetActiveCLSID is a wrapper around sugar.GetActiveCLSID that automatically tracks the chain.
+func (c *sugarContext) GetActiveCLSID(clsid *ole.GUID) Chain {
+	return c.Track(GetActiveCLSID(clsid))
+}
+
 // From is a wrapper around sugar.From that automatically tracks the chain.
 func (c *sugarContext) From(disp *ole.IDispatch) Chain {
 	return c.Track(From(disp))
 }
 
-// Release releases all tracked chains in LIFO order.
+// FromDispatcher is a wrapper around sugar.FromDispatcher that automatically tracks the chain.
+func (c *sugarContext) FromDispatcher(d Dispatcher) Chain {
+	return c.Track(FromDispatcher(d))
+}
+
+// Release releases all tracked chains in LIFO order. Chains that were
+// already released, or are tracked more than once, are released only once.
 func (c *sugarContext) Release() error {
-	if c.chains == nil {
-		return nil
+	c.mu.Lock()
+	chains := c.chains
+	observers := c.observers
+	c.chains = nil
+	c.pending = nil
+	c.observers = nil
+	c.mu.Unlock()
+
+	for _, o := range observers {
+		o.finish(nil)
 	}
+
 	var firstErr error
+	for i := len(chains) - 1; i >= 0; i-- {
+		if err := chains[i].Release(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Detach removes ch from the tracked chains without releasing it.
+func (c *sugarContext) Detach(ch Chain) Chain {
+	c.mu.Lock()
+	c.untrack(ch)
+	c.mu.Unlock()
+	return ch
+}
+
+// ReleaseChain releases a single tracked chain ahead of the Context.
+func (c *sugarContext) ReleaseChain(ch Chain) error {
+	c.mu.Lock()
+	tracked := c.untrack(ch)
+	c.mu.Unlock()
+	if !tracked {
+		return fmt.Errorf("chain %T is not tracked by this context", ch)
+	}
+	return ch.Release()
+}
+
+// releaseLater queues ch to be released by the next releasePending.
+func (c *sugarContext) releaseLater(ch Chain) {
+	c.mu.Lock()
+	c.pending = append(c.pending, ch)
+	c.mu.Unlock()
+}
+
+// releasePending releases the chains queued by releaseLater. It runs on the
+// thread making COM calls through this Context, which keeps the releases in
+// the apartment that owns the objects.
+func (c *sugarContext) releasePending() {
+	c.mu.Lock()
+	if len(c.pending) == 0 {
+		c.mu.Unlock()
+		return
+	}
+	pending := c.pending
+	c.pending = nil
+	for _, ch := range pending {
+		c.untrack(ch)
+	}
+	c.mu.Unlock()
+
+	for _, ch := range pending {
+		ch.Release()
+	}
+}
+
+// untrack removes the most recently tracked entry for ch and reports whether
+// there was one. Wrappers embedding a Chain, such as those of the excel
+// package, match the chain they wrap. The caller must hold c.mu.
+func (c *sugarContext) untrack(ch Chain) bool {
+	target := unwrapChain(ch)
 	for i := len(c.chains) - 1; i >= 0; i-- {
-		if err := c.chains[i].Release(); err != nil && firstErr == nil {
+		if c.chains[i].Chain == ch || unwrapChain(c.chains[i].Chain) == target {
+			copy(c.chains[i:], c.chains[i+1:])
+			c.chains[len(c.chains)-1] = trackedChain{}
+			c.chains = c.chains[:len(c.chains)-1]
+			return true
+		}
+	}
+	return false
+}
+
+// unwrapChain returns the innermost Chain embedded in ch.
+func unwrapChain(ch Chain) Chain {
+	for {
+		if _, ok := ch.(*chain); ok || ch == nil {
+			return ch
+		}
+		v := reflect.ValueOf(ch)
+		if v.Kind() == reflect.Pointer {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return ch
+		}
+		field := v.FieldByName("Chain")
+		if !field.IsValid() || field.Kind() != reflect.Interface || field.IsNil() {
+			return ch
+		}
+		inner, ok := field.Interface().(Chain)
+		if !ok {
+			return ch
+		}
+		ch = inner
+	}
+}
+
+// Len returns the number of tracked chains.
+func (c *sugarContext) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.chains)
+}
+
+// Stats returns a breakdown of the tracked chains.
+func (c *sugarContext) Stats() ContextStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := ContextStats{Tracked: len(c.chains), Pending: len(c.pending)}
+	for _, ch := range c.chains {
+		if impl, ok := unwrapChain(ch.Chain).(*chain); ok && !impl.root {
+			s.Derived++
+		} else {
+			s.Roots++
+		}
+	}
+	return s
+}
+
+// Mark returns the sequence number of the next tracked chain. Unlike a
+// position in c.chains, it stays valid when chains are untracked.
+func (c *sugarContext) Mark() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.seq
+}
+
+// Rollback releases every chain tracked since mark in LIFO order.
+func (c *sugarContext) Rollback(mark int) error {
+	c.mu.Lock()
+	if mark < 0 || mark > c.seq {
+		seq := c.seq
+		c.mu.Unlock()
+		return fmt.Errorf("invalid mark %d: context has tracked %d chains", mark, seq)
+	}
+	// Sequence numbers grow along c.chains, so the chains to release are a
+	// suffix of it.
+	i := len(c.chains)
+	for i > 0 && c.chains[i-1].seq >= mark {
+		i--
+	}
+	released := make([]Chain, 0, len(c.chains)-i)
+	for j := i; j < len(c.chains); j++ {
+		released = append(released, c.chains[j].Chain)
+		c.chains[j] = trackedChain{}
+	}
+	c.chains = c.chains[:i]
+	// No tracked chain is numbered mark or above any more, so numbering can
+	// resume from it.
+	c.seq = mark
+	c.mu.Unlock()
+
+	var firstErr error
+	for i := len(released) - 1; i >= 0; i-- {
+		if err := released[i].Release(); err != nil && firstErr == nil {
 			firstErr = err
 		}
 	}
-	c.chains = nil
 	return firstErr
 }
 
+// Transaction rolls back the chains tracked by fn if it fails.
+func (c *sugarContext) Transaction(fn func(ctx Context) error) (err error) {
+	mark := c.Mark()
+	defer func() {
+		if r := recover(); r != nil {
+			c.Rollback(mark)
+			panic(r)
+		}
+		if err != nil {
+			if rerr := c.Rollback(mark); rerr != nil {
+				err = errors.Join(err, rerr)
+			}
+		}
+	}()
+	return fn(c)
+}
+
 // Do executes the function within a nested scope of this context.
 func (c *sugarContext) Do(fn func(ctx Context) error) error {
 	return With(c).Do(fn)
@@ -93,4 +417,10 @@ func (c *sugarContext) Do(fn func(ctx Context) error) error {
 // Go executes the function in a new goroutine branching from this context.
 func (c *sugarContext) Go(fn func(ctx Context) error) {
 	With(c).Go(fn)
+}
+
+// GoResult executes the function in a new goroutine branching from this
+// context and returns a channel delivering its error.
+func (c *sugarContext) GoResult(fn func(ctx Context) error) <-chan error {
+	return With(c).GoResult(fn)
 }
\ No newline at end of file
@@ -4,8 +4,17 @@ package sugar_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
+	"unsafe"
 
 	"github.com/xll-gen/sugar"
 )
@@ -98,4 +107,466 @@ func TestContext_WithCancel(t *testing.T) {
 		}
 		return nil
 	})
-}
\ No newline at end of file
+}
+var procGetCurrentThreadId = syscall.NewLazyDLL("kernel32.dll").NewProc("GetCurrentThreadId")
+
+func currentThreadID() uintptr {
+	id, _, _ := procGetCurrentThreadId.Call()
+	return id
+}
+
+// releaseRecorder is a Chain that records when and where it was released.
+type releaseRecorder struct {
+	sugar.Chain
+	released bool
+	threadID uintptr
+}
+
+func (r *releaseRecorder) Release() error {
+	r.released = true
+	r.threadID = currentThreadID()
+	return nil
+}
+
+func TestContext_OnShutdown(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		staThread := currentThreadID()
+
+		subCtx := sugar.NewContext(ctx)
+		rec := &releaseRecorder{}
+		subCtx.Track(rec)
+
+		signals := make(chan os.Signal, 1)
+		shutdown := sugar.OnShutdown(subCtx, signals)
+		defer shutdown.Stop()
+
+		quit := false
+		shutdown.Defer(func() { quit = true })
+
+		if shutdown.Check() {
+			t.Fatal("Check reported shutdown before any signal")
+		}
+
+		go func() { signals <- os.Interrupt }()
+		if sig := shutdown.Wait(); sig != os.Interrupt {
+			t.Errorf("expected os.Interrupt, got %v", sig)
+		}
+
+		if !quit {
+			t.Error("expected deferred function to run")
+		}
+		if !rec.released {
+			t.Fatal("expected context to be released")
+		}
+		if rec.threadID != staThread {
+			t.Errorf("released on thread %d, expected STA thread %d", rec.threadID, staThread)
+		}
+		return nil
+	})
+}
+
+func TestContext_MarkRollback(t *testing.T) {
+	ctx := sugar.NewContext(context.Background())
+	defer ctx.Release()
+
+	before := &releaseRecorder{}
+	ctx.Track(before)
+
+	mark := ctx.Mark()
+	created := []*releaseRecorder{{}, {}, {}}
+	for _, rec := range created {
+		ctx.Track(rec)
+	}
+
+	if err := ctx.Rollback(mark); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	for i, rec := range created {
+		if !rec.released {
+			t.Errorf("expected chain %d to be released", i)
+		}
+	}
+	if before.released {
+		t.Error("chain tracked before the mark must not be released")
+	}
+	if got := ctx.Mark(); got != mark {
+		t.Errorf("expected %d tracked chains after rollback, got %d", mark, got)
+	}
+
+	if err := ctx.Rollback(mark + 1); err == nil {
+		t.Error("expected error for a mark beyond the tracked chains")
+	}
+}
+
+func TestContext_RollbackAfterDetach(t *testing.T) {
+	ctx := sugar.NewContext(context.Background())
+	defer ctx.Release()
+
+	before := &releaseRecorder{}
+	ctx.Track(before)
+	mark := ctx.Mark()
+
+	detached, released := &releaseRecorder{}, &releaseRecorder{}
+	ctx.Track(detached)
+	ctx.Track(released)
+	// Untracking a chain from before the mark must not shift the mark.
+	ctx.Detach(before)
+	ctx.Detach(detached)
+
+	if err := ctx.Rollback(mark); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if !released.released {
+		t.Error("expected the chain tracked after the mark to be released")
+	}
+	if detached.released || before.released {
+		t.Error("detached chains must not be released by Rollback")
+	}
+	if got := ctx.Len(); got != 0 {
+		t.Errorf("expected no tracked chains, got %d", got)
+	}
+}
+
+func TestContext_Detach(t *testing.T) {
+	ctx := sugar.NewContext(context.Background())
+
+	kept := &releaseRecorder{}
+	detached := &releaseRecorder{}
+	ctx.Track(kept)
+	ctx.Track(detached)
+
+	if got := ctx.Detach(detached); got != detached {
+		t.Errorf("expected Detach to return its argument")
+	}
+	ctx.Detach(&releaseRecorder{}) // untracked: no-op
+
+	if got := ctx.Len(); got != 1 {
+		t.Errorf("expected 1 tracked chain after Detach, got %d", got)
+	}
+	ctx.Release()
+	if detached.released {
+		t.Error("detached chain must not be released by the context")
+	}
+	if !kept.released {
+		t.Error("expected the remaining chain to be released")
+	}
+}
+
+func TestContext_ReleaseChain(t *testing.T) {
+	ctx := sugar.NewContext(context.Background())
+	defer ctx.Release()
+
+	early := &releaseRecorder{}
+	ctx.Track(early)
+
+	if err := ctx.ReleaseChain(early); err != nil {
+		t.Fatalf("ReleaseChain failed: %v", err)
+	}
+	if !early.released {
+		t.Error("expected chain to be released")
+	}
+	if got := ctx.Len(); got != 0 {
+		t.Errorf("expected no tracked chains, got %d", got)
+	}
+	if err := ctx.ReleaseChain(early); err == nil {
+		t.Error("expected error releasing an untracked chain")
+	}
+}
+
+func TestContext_ConcurrentTrack(t *testing.T) {
+	ctx := sugar.NewContext(context.Background())
+
+	const workers, perWorker = 8, 100
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				rec := &releaseRecorder{}
+				ctx.Track(rec)
+				if i%2 == 0 {
+					ctx.ReleaseChain(rec)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := ctx.Len(); got != workers*perWorker/2 {
+		t.Errorf("expected %d tracked chains, got %d", workers*perWorker/2, got)
+	}
+	ctx.Release()
+}
+
+var procCoGetApartmentType = syscall.NewLazyDLL("ole32.dll").NewProc("CoGetApartmentType")
+
+func apartmentType(t *testing.T) int32 {
+	var aptType, qualifier int32
+	hr, _, _ := procCoGetApartmentType.Call(uintptr(unsafe.Pointer(&aptType)), uintptr(unsafe.Pointer(&qualifier)))
+	if hr != 0 {
+		t.Fatalf("CoGetApartmentType failed: 0x%08X", hr)
+	}
+	return aptType
+}
+
+func TestRunner_WithApartment(t *testing.T) {
+	const aptSTA, aptMTA, aptMainSTA = 0, 1, 3
+
+	sugar.With(context.Background()).Do(func(ctx sugar.Context) error {
+		if apt := apartmentType(t); apt != aptSTA && apt != aptMainSTA {
+			t.Errorf("expected an STA by default, got apartment type %d", apt)
+		}
+		return nil
+	})
+
+	err := sugar.With(context.Background()).WithApartment(sugar.MTA).Do(func(ctx sugar.Context) error {
+		if apt := apartmentType(t); apt != aptMTA {
+			t.Errorf("expected the MTA, got apartment type %d", apt)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("MTA Do failed: %v", err)
+	}
+}
+
+var (
+	procPostThreadMessageW = syscall.NewLazyDLL("user32.dll").NewProc("PostThreadMessageW")
+	procPeekMessageW       = syscall.NewLazyDLL("user32.dll").NewProc("PeekMessageW")
+)
+
+func TestRunner_WithMessagePump(t *testing.T) {
+	const wmApp = 0x8000
+
+	sugar.With(context.Background()).WithMessagePump().Do(func(ctx sugar.Context) error {
+		procPostThreadMessageW.Call(currentThreadID(), wmApp, 0, 0)
+		if err := sugar.Wait(ctx, 20*time.Millisecond); err != nil {
+			t.Fatalf("Wait failed: %v", err)
+		}
+		var msg [64]byte
+		if ok, _, _ := procPeekMessageW.Call(uintptr(unsafe.Pointer(&msg[0])), 0, 0, 0, 0); ok != 0 {
+			t.Error("expected Wait to pump the queued message")
+		}
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		if err := sugar.Wait(cancelled, time.Second); err != context.Canceled {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		return nil
+	})
+}
+
+func TestContext_Transaction(t *testing.T) {
+	ctx := sugar.NewContext(context.Background())
+	defer ctx.Release()
+
+	before := &releaseRecorder{}
+	ctx.Track(before)
+
+	var created []*releaseRecorder
+	errBuild := errors.New("build failed")
+	err := ctx.Transaction(func(ctx sugar.Context) error {
+		for i := 0; i < 3; i++ {
+			rec := &releaseRecorder{}
+			created = append(created, rec)
+			ctx.Track(rec)
+		}
+		return errBuild
+	})
+	if !errors.Is(err, errBuild) {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+	for i, rec := range created {
+		if !rec.released {
+			t.Errorf("expected chain %d to be released", i)
+		}
+	}
+	if before.released || ctx.Len() != 1 {
+		t.Error("expected chains tracked before the transaction to be kept")
+	}
+
+	kept := &releaseRecorder{}
+	if err := ctx.Transaction(func(ctx sugar.Context) error {
+		ctx.Track(kept)
+		return nil
+	}); err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+	if kept.released || ctx.Len() != 2 {
+		t.Error("expected a successful transaction to keep its chains")
+	}
+}
+
+func TestContext_Len(t *testing.T) {
+	ctx := sugar.NewContext(context.Background())
+	defer ctx.Release()
+
+	a, b := &releaseRecorder{}, &releaseRecorder{}
+	ctx.Track(a)
+	ctx.Track(b)
+	if got := ctx.Len(); got != 2 {
+		t.Errorf("expected 2 tracked chains, got %d", got)
+	}
+
+	ctx.Detach(a)
+	ctx.ReleaseChain(b)
+	if got := ctx.Len(); got != 0 {
+		t.Errorf("expected no tracked chains after Detach and ReleaseChain, got %d", got)
+	}
+}
+
+func TestRunner_RecoverPanic(t *testing.T) {
+	rec := &releaseRecorder{}
+	err := sugar.Do(func(ctx sugar.Context) error {
+		ctx.Track(rec)
+		var ch sugar.Chain
+		ch.Err() // nil interface: runtime error
+		return nil
+	})
+
+	var perr *sugar.PanicError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *PanicError, got %v", err)
+	}
+	var rerr runtime.Error
+	if !errors.As(err, &rerr) {
+		t.Errorf("expected PanicError to unwrap to a runtime.Error, got %v", perr.Value)
+	}
+	if len(perr.Stack) == 0 {
+		t.Error("expected a stack trace")
+	}
+	if !rec.released {
+		t.Error("expected tracked chains to be released after a panic")
+	}
+
+	// COM must still be usable on this thread afterwards.
+	if err := sugar.Do(func(ctx sugar.Context) error { return nil }); err != nil {
+		t.Errorf("Do after a recovered panic failed: %v", err)
+	}
+}
+
+func TestRunner_WithRawPanics(t *testing.T) {
+	defer func() {
+		if v := recover(); v != "boom" {
+			t.Errorf("expected raw panic \"boom\", got %v", v)
+		}
+	}()
+	sugar.With(context.Background()).WithRawPanics().Do(func(ctx sugar.Context) error {
+		panic("boom")
+	})
+	t.Error("expected Do to panic")
+}
+
+func TestRunner_WithTimeout(t *testing.T) {
+	err := sugar.With(context.Background()).WithTimeout(20 * time.Millisecond).Do(func(ctx sugar.Context) error {
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Error("expected the context to be done after the timeout")
+		}
+		return nil
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	err = sugar.With(context.Background()).WithTimeout(time.Second).Do(func(ctx sugar.Context) error {
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected no error within the timeout, got %v", err)
+	}
+}
+
+func TestRunner_GoResult(t *testing.T) {
+	want := errors.New("boom")
+	err := <-sugar.GoResult(func(ctx sugar.Context) error {
+		return want
+	})
+	if err != want {
+		t.Errorf("expected %v, got %v", want, err)
+	}
+
+	sugar.Do(func(ctx sugar.Context) error {
+		done := ctx.GoResult(func(asyncCtx sugar.Context) error {
+			panic("async panic")
+		})
+		var panicErr *sugar.PanicError
+		if err := <-done; !errors.As(err, &panicErr) {
+			t.Errorf("expected a *PanicError, got %v", err)
+		}
+		if _, ok := <-done; ok {
+			t.Error("expected the channel to be closed after the result")
+		}
+		return nil
+	})
+}
+
+func TestParallelDo(t *testing.T) {
+	var sum atomic.Int64
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	err := sugar.ParallelDo(3, items, func(ctx sugar.Context, item int) error {
+		sum.Add(int64(item))
+		if item%4 == 0 {
+			return fmt.Errorf("item %d failed", item)
+		}
+		return nil
+	})
+	if sum.Load() != 36 {
+		t.Errorf("expected every item to be processed, got sum %d", sum.Load())
+	}
+	if err == nil || !strings.Contains(err.Error(), "item 4 failed") || !strings.Contains(err.Error(), "item 8 failed") {
+		t.Errorf("expected the errors of items 4 and 8, got %v", err)
+	}
+
+	parent, cancel := context.WithCancel(context.Background())
+	cancel()
+	var calls atomic.Int64
+	err = sugar.ParallelDoWith(sugar.With(parent), 2, items, func(ctx sugar.Context, item int) error {
+		calls.Add(1)
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("expected no items to run after cancellation, got %d", calls.Load())
+	}
+
+	// Every worker must see the same deadline: one per worker would let the
+	// call run longer than the timeout when workers start at different times.
+	var mu sync.Mutex
+	deadlines := map[time.Time]bool{}
+	err = sugar.ParallelDoWith(sugar.With(context.Background()).WithTimeout(time.Minute), 3, items,
+		func(ctx sugar.Context, item int) error {
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				return fmt.Errorf("item %d: no deadline", item)
+			}
+			mu.Lock()
+			deadlines[deadline] = true
+			mu.Unlock()
+			return nil
+		})
+	if err != nil {
+		t.Errorf("ParallelDoWith failed: %v", err)
+	}
+	if len(deadlines) != 1 {
+		t.Errorf("expected a single deadline across workers, got %d", len(deadlines))
+	}
+}
+
+func TestRunner_WithSecurity(t *testing.T) {
+	// Security may already be set by earlier tests; either way Do succeeds
+	// and later runners leave it alone.
+	for i := 0; i < 2; i++ {
+		err := sugar.With(context.Background()).
+			WithSecurity(sugar.AuthLevelConnect, sugar.ImpLevelImpersonate).
+			Do(func(ctx sugar.Context) error { return nil })
+		if err != nil {
+			t.Fatalf("Do %d with security failed: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+//go:build windows
+
+package sugar
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+
+	"github.com/go-ole/go-ole"
+)
+
+// variantValue decodes v like ole.VARIANT.Value, with a precise conversion of
+// VT_DATE that keeps milliseconds. VT_CY becomes a Currency and VT_DECIMAL an
+// exact *big.Rat, which go-ole does not decode.
+func variantValue(v *ole.VARIANT) interface{} {
+	switch v.VT {
+	case ole.VT_DATE:
+		return oaDateToTime(math.Float64frombits(uint64(v.Val)))
+	case ole.VT_CY:
+		return Currency(v.Val)
+	case ole.VT_DECIMAL:
+		return decimalToRat(v)
+	}
+	return v.Value()
+}
+
+func toString(v interface{}) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("cannot convert %T to string", v)
+}
+
+func toInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int8:
+		return int64(n), nil
+	case int16:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint:
+		return uintToInt64(uint64(n))
+	case uint8:
+		return int64(n), nil
+	case uint16:
+		return int64(n), nil
+	case uint32:
+		return int64(n), nil
+	case uint64:
+		return uintToInt64(n)
+	case float32:
+		return floatToInt64(float64(n))
+	case float64:
+		return floatToInt64(n)
+	case Currency:
+		if n%CurrencyScale != 0 {
+			return 0, fmt.Errorf("value %v is not representable as int64", n)
+		}
+		return int64(n / CurrencyScale), nil
+	case *big.Rat:
+		if !n.IsInt() || !n.Num().IsInt64() {
+			return 0, fmt.Errorf("value %v is not representable as int64", n.RatString())
+		}
+		return n.Num().Int64(), nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to int64", v)
+}
+
+func uintToInt64(n uint64) (int64, error) {
+	if n > math.MaxInt64 {
+		return 0, fmt.Errorf("value %d overflows int64", n)
+	}
+	return int64(n), nil
+}
+
+// floatToInt64 accepts integral floats, since automation servers such as
+// Excel report every number as a double. math.MaxInt64 rounds up to 2^63 as a
+// float64, which is already out of range, hence >=.
+func floatToInt64(f float64) (int64, error) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, fmt.Errorf("value %v is not representable as int64", f)
+	}
+	return int64(f), nil
+}
+
+func toFloat64(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float32:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	case Currency:
+		return float64(n) / CurrencyScale, nil
+	case *big.Rat:
+		f, _ := n.Float64()
+		return f, nil
+	}
+	i, err := toInt64(v)
+	if err != nil {
+		return 0, fmt.Errorf("cannot convert %T to float64", v)
+	}
+	return float64(i), nil
+}
+
+// toBool accepts VT_BOOL values as well as numbers, treating non-zero as true.
+func toBool(v interface{}) (bool, error) {
+	if b, ok := v.(bool); ok {
+		return b, nil
+	}
+	f, err := toFloat64(v)
+	if err != nil {
+		return false, fmt.Errorf("cannot convert %T to bool", v)
+	}
+	return f != 0, nil
+}
+
+// coerce converts a value returned by Value into T. Numeric values are widened
+// or narrowed with overflow checks, and named types (such as enums) are
+// supported through their underlying kind.
+func coerce[T any](v interface{}) (T, error) {
+	var zero T
+	if t, ok := v.(T); ok {
+		return t, nil
+	}
+	out, err := coerceValue(v, reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return zero, err
+	}
+	return out.Interface().(T), nil
+}
+
+// coerceValue is coerce for a type known at run time.
+func coerceValue(v interface{}, rt reflect.Type) (reflect.Value, error) {
+	if v == nil {
+		if rt.Kind() == reflect.Interface {
+			return reflect.Zero(rt), nil
+		}
+		return reflect.Value{}, fmt.Errorf("cannot convert nil to %v", rt)
+	}
+	if reflect.TypeOf(v) == rt {
+		return reflect.ValueOf(v), nil
+	}
+
+	out := reflect.New(rt).Elem()
+	switch rt.Kind() {
+	case reflect.String:
+		s, err := toString(v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		out.SetString(s)
+	case reflect.Bool:
+		b, err := toBool(v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		out.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt64(v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if out.OverflowInt(n) {
+			return reflect.Value{}, fmt.Errorf("value %d overflows %v", n, rt)
+		}
+		out.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := toInt64(v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if n < 0 || out.OverflowUint(uint64(n)) {
+			return reflect.Value{}, fmt.Errorf("value %d overflows %v", n, rt)
+		}
+		out.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat64(v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		out.SetFloat(f)
+	default:
+		rv := reflect.ValueOf(v)
+		if !rv.Type().ConvertibleTo(rt) {
+			return reflect.Value{}, fmt.Errorf("cannot convert %T to %v", v, rt)
+		}
+		out.Set(rv.Convert(rt))
+	}
+	return out, nil
+}
@@ -0,0 +1,94 @@
+//go:build windows
+
+package sugar
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"unsafe"
+
+	"github.com/go-ole/go-ole"
+)
+
+// Currency is an exact monetary amount as stored in a VT_CY VARIANT: an
+// int64 count of ten-thousandths, so Currency(12345) is 1.2345. Value returns
+// VT_CY results, such as Excel cells formatted as currency, as a Currency,
+// and a Currency passed to Put or Call is sent as VT_CY.
+type Currency int64
+
+// CurrencyScale is the number of Currency units in one.
+const CurrencyScale = 10000
+
+// Rat returns the amount as an exact rational number.
+func (c Currency) Rat() *big.Rat {
+	return big.NewRat(int64(c), CurrencyScale)
+}
+
+// String formats the amount with four decimal places, such as "-1.2345".
+func (c Currency) String() string {
+	return c.Rat().FloatString(4)
+}
+
+// decimal mirrors the Windows DECIMAL structure, which fills a whole VARIANT;
+// its reserved first field overlaps the VARIANT's type.
+type decimal struct {
+	reserved uint16
+	scale    byte
+	sign     byte
+	hi32     uint32
+	lo64     uint64
+}
+
+const (
+	decimalNegative = 0x80
+	decimalMaxScale = 28
+)
+
+// decimalToRat converts a VT_DECIMAL VARIANT to an exact rational number.
+func decimalToRat(v *ole.VARIANT) *big.Rat {
+	d := (*decimal)(unsafe.Pointer(v))
+	mantissa := new(big.Int).SetUint64(uint64(d.hi32))
+	mantissa.Lsh(mantissa, 64)
+	mantissa.Or(mantissa, new(big.Int).SetUint64(d.lo64))
+	if d.sign&decimalNegative != 0 {
+		mantissa.Neg(mantissa)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.scale)), nil)
+	return new(big.Rat).SetFrac(mantissa, scale)
+}
+
+// ratToDecimal converts r to a VT_DECIMAL VARIANT: a 96-bit integer divided
+// by a power of ten of at most 28. Numbers that do not fit exactly, such as
+// 1/3, are rejected rather than rounded.
+func ratToDecimal(r *big.Rat) (ole.VARIANT, error) {
+	if r == nil {
+		return ole.VARIANT{}, errors.New("nil *big.Rat")
+	}
+	ten := big.NewRat(10, 1)
+	m := new(big.Rat).Abs(r)
+	scale := 0
+	for !m.IsInt() {
+		if scale == decimalMaxScale {
+			return ole.VARIANT{}, fmt.Errorf("value %s has no exact decimal representation", r.RatString())
+		}
+		m.Mul(m, ten)
+		scale++
+	}
+	mantissa := m.Num()
+	if mantissa.BitLen() > 96 {
+		return ole.VARIANT{}, fmt.Errorf("value %s overflows a 96-bit decimal", r.RatString())
+	}
+
+	var v ole.VARIANT
+	d := (*decimal)(unsafe.Pointer(&v))
+	d.scale = byte(scale)
+	if r.Sign() < 0 {
+		d.sign = decimalNegative
+	}
+	lo := new(big.Int).And(mantissa, new(big.Int).SetUint64(^uint64(0)))
+	d.lo64 = lo.Uint64()
+	d.hi32 = uint32(new(big.Int).Rsh(mantissa, 64).Uint64())
+	v.VT = ole.VT_DECIMAL
+	return v, nil
+}
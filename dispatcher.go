@@ -0,0 +1,464 @@
+//go:build windows
+
+package sugar
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+
+	"github.com/go-ole/go-ole"
+)
+
+// ErrUnknownMember is returned by a Dispatcher for a property or method it does
+// not have. It is reported to the caller as DISP_E_MEMBERNOTFOUND, like a real
+// automation object would.
+var ErrUnknownMember = errors.New("unknown member")
+
+// Dispatcher is an automation object implemented in Go. FromDispatcher exposes
+// it as an IDispatch, so that every Chain operation works on it exactly as on
+// a COM object; the sugartest package builds in-memory fakes on it for tests
+// that should not depend on Excel.
+//
+// Arguments arrive as the Go values Value would return for them, and omitted
+// arguments as Missing. Objects
+// created with FromDispatcher arrive as their Dispatcher, other objects as a
+// Chain that is only valid during the call. Results may be nil, any value
+// accepted as a SetValues2D cell, a []interface{} (as a 1D array), a
+// [][]interface{} (as a 2D array), a Dispatcher or an *ole.IDispatch.
+type Dispatcher interface {
+	// Get reads a property, passing args for parameterized properties such as
+	// Item. The default member has the name "".
+	Get(name string, args ...interface{}) (interface{}, error)
+	// Put writes a property. The value is the last element of args.
+	Put(name string, args ...interface{}) error
+	// Call invokes a method.
+	Call(name string, args ...interface{}) (interface{}, error)
+	// Enum returns the items of a collection, for ForEach and friends. Objects
+	// that are not collections return an error.
+	Enum() ([]interface{}, error)
+}
+
+// FromDispatcher starts a new chain on a Go implementation of an automation
+// object.
+func FromDispatcher(d Dispatcher) Chain {
+	disp := newGoDispatch(d)
+	return &chain{
+		disp:   disp,
+		thread: ownerThread(),
+	}
+}
+
+const (
+	dispEMemberNotFound = 0x80020003
+	dispEException      = 0x80020009
+	dispEUnknownName    = 0x80020006
+	sFalse              = 1
+)
+
+// excepInfo mirrors EXCEPINFO, whose fields go-ole does not export.
+type excepInfo struct {
+	wCode             uint16
+	wReserved         uint16
+	bstrSource        *int16
+	bstrDescription   *int16
+	bstrHelpFile      *int16
+	dwHelpContext     uint32
+	pvReserved        uintptr
+	pfnDeferredFillIn uintptr
+	scode             uint32
+}
+
+// goDispatch is an IDispatch implemented in Go that forwards to a Dispatcher.
+// Member names are assigned DISPIDs on first use. The vtbl field must come
+// first.
+type goDispatch struct {
+	vtbl *[7]uintptr
+	ref  int32
+	d    Dispatcher
+
+	mu    sync.Mutex
+	names []string
+	ids   map[string]int32
+}
+
+// goEnum is an IEnumVARIANT implemented in Go over a snapshot of items.
+type goEnum struct {
+	vtbl  *[7]uintptr
+	ref   int32
+	items []interface{}
+	pos   int
+}
+
+var (
+	goVtblOnce sync.Once
+	goDispVtbl [7]uintptr
+	goEnumVtbl [7]uintptr
+	// liveGoObjects keeps objects handed out to COM reachable, keyed by their
+	// address, until their last reference is released.
+	liveGoObjects sync.Map
+)
+
+func initGoVtbls() {
+	goVtblOnce.Do(func() {
+		goDispVtbl = [7]uintptr{
+			syscall.NewCallback(goDispQueryInterface),
+			syscall.NewCallback(goDispAddRef),
+			syscall.NewCallback(goDispRelease),
+			syscall.NewCallback(goDispGetTypeInfoCount),
+			syscall.NewCallback(goDispGetTypeInfo),
+			syscall.NewCallback(goDispGetIDsOfNames),
+			syscall.NewCallback(goDispInvoke),
+		}
+		goEnumVtbl = [7]uintptr{
+			syscall.NewCallback(goEnumQueryInterface),
+			syscall.NewCallback(goEnumAddRef),
+			syscall.NewCallback(goEnumRelease),
+			syscall.NewCallback(goEnumNext),
+			syscall.NewCallback(goEnumSkip),
+			syscall.NewCallback(goEnumReset),
+			syscall.NewCallback(goEnumClone),
+		}
+	})
+}
+
+// newGoDispatch returns an IDispatch for d holding one reference.
+func newGoDispatch(d Dispatcher) *ole.IDispatch {
+	initGoVtbls()
+	g := &goDispatch{vtbl: &goDispVtbl, ref: 1, d: d, ids: map[string]int32{}}
+	liveGoObjects.Store(uintptr(unsafe.Pointer(g)), g)
+	return (*ole.IDispatch)(unsafe.Pointer(g))
+}
+
+func newGoEnum(items []interface{}) *goEnum {
+	initGoVtbls()
+	e := &goEnum{vtbl: &goEnumVtbl, ref: 1, items: items}
+	liveGoObjects.Store(uintptr(unsafe.Pointer(e)), e)
+	return e
+}
+
+// dispatcherOf returns the Dispatcher behind disp if it was created by
+// FromDispatcher.
+func dispatcherOf(disp *ole.IDispatch) (Dispatcher, bool) {
+	obj, ok := liveGoObjects.Load(uintptr(unsafe.Pointer(disp)))
+	if !ok {
+		return nil, false
+	}
+	g, ok := obj.(*goDispatch)
+	if !ok {
+		return nil, false
+	}
+	return g.d, true
+}
+
+func goDispQueryInterface(g *goDispatch, iid *ole.GUID, ppv **goDispatch) uintptr {
+	if ole.IsEqualGUID(iid, ole.IID_IUnknown) || ole.IsEqualGUID(iid, ole.IID_IDispatch) {
+		goDispAddRef(g)
+		*ppv = g
+		return ole.S_OK
+	}
+	*ppv = nil
+	return ole.E_NOINTERFACE
+}
+
+func goDispAddRef(g *goDispatch) uintptr {
+	return uintptr(atomic.AddInt32(&g.ref, 1))
+}
+
+func goDispRelease(g *goDispatch) uintptr {
+	n := atomic.AddInt32(&g.ref, -1)
+	if n == 0 {
+		liveGoObjects.Delete(uintptr(unsafe.Pointer(g)))
+	}
+	return uintptr(n)
+}
+
+func goDispGetTypeInfoCount(g *goDispatch, count *uint32) uintptr {
+	*count = 0
+	return ole.S_OK
+}
+
+func goDispGetTypeInfo(g *goDispatch, _, _ uintptr, _ *uintptr) uintptr {
+	return ole.E_NOTIMPL
+}
+
+func goDispGetIDsOfNames(g *goDispatch, _ *ole.GUID, names **uint16, count uint32, _ uintptr, ids *int32) uintptr {
+	if count == 0 {
+		return ole.S_OK
+	}
+	out := unsafe.Slice(ids, count)
+	out[0] = g.id(ole.LpOleStrToString(*names))
+	if count == 1 {
+		return ole.S_OK
+	}
+	// Named arguments are not supported.
+	for i := 1; i < len(out); i++ {
+		out[i] = ole.DISPID_UNKNOWN
+	}
+	return dispEUnknownName
+}
+
+// id returns the DISPID of name, assigning one on first use. Names are case
+// insensitive, as in Automation.
+func (g *goDispatch) id(name string) int32 {
+	key := strings.ToLower(name)
+	switch key {
+	case "":
+		return ole.DISPID_VALUE
+	case "_newenum":
+		return ole.DISPID_NEWENUM
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if id, ok := g.ids[key]; ok {
+		return id
+	}
+	g.names = append(g.names, name)
+	id := int32(len(g.names))
+	g.ids[key] = id
+	return id
+}
+
+func (g *goDispatch) name(id int32) (string, bool) {
+	if id == ole.DISPID_VALUE {
+		return "", true
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if id < 1 || int(id) > len(g.names) {
+		return "", false
+	}
+	return g.names[id-1], true
+}
+
+func goDispInvoke(g *goDispatch, dispid int32, _ *ole.GUID, _, flags uintptr, params *dispParams, result *ole.VARIANT, info *excepInfo, _ uintptr) (hr uintptr) {
+	defer func() {
+		// A panic must not unwind into the caller's COM runtime.
+		if r := recover(); r != nil {
+			hr = setException(info, fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	var out interface{}
+	var err error
+	if dispid == ole.DISPID_NEWENUM {
+		var items []interface{}
+		if items, err = g.d.Enum(); err == nil {
+			out = newGoEnum(items)
+		}
+	} else {
+		name, ok := g.name(dispid)
+		if !ok {
+			return dispEMemberNotFound
+		}
+		args := dispArgs(params)
+		switch {
+		case int16(flags)&(ole.DISPATCH_PROPERTYPUT|ole.DISPATCH_PROPERTYPUTREF) != 0:
+			err = g.d.Put(name, args...)
+		case int16(flags)&ole.DISPATCH_METHOD != 0:
+			out, err = g.d.Call(name, args...)
+		default:
+			out, err = g.d.Get(name, args...)
+		}
+	}
+	if errors.Is(err, ErrUnknownMember) {
+		return dispEMemberNotFound
+	}
+	if err != nil {
+		return setException(info, err)
+	}
+
+	v, err := resultVariant(out)
+	if err != nil {
+		return setException(info, err)
+	}
+	if result != nil {
+		*result = v
+	} else {
+		ole.VariantClear(&v)
+	}
+	return ole.S_OK
+}
+
+// dispArgs converts DISPPARAMS to Go values in call order.
+func dispArgs(params *dispParams) []interface{} {
+	var vars []ole.VARIANT
+	if params != nil && params.cArgs > 0 {
+		vars = unsafe.Slice(params.rgvarg, params.cArgs)
+	}
+	// Arguments arrive in reverse order.
+	args := make([]interface{}, len(vars))
+	for i := range args {
+		v := derefVariant(&vars[len(vars)-1-i])
+		switch v.VT {
+		case ole.VT_DISPATCH:
+			disp := v.ToIDispatch()
+			if d, ok := dispatcherOf(disp); ok {
+				args[i] = d
+			} else {
+				args[i] = &chain{disp: disp, borrowed: true, thread: ownerThread()}
+			}
+		case ole.VT_ERROR:
+			if uint32(v.Val) == dispEParamNotFound {
+				args[i] = Missing
+			} else {
+				args[i] = variantValue(v)
+			}
+		case ole.VT_ARRAY | ole.VT_VARIANT:
+			// Two-dimensional arrays, as written by SetValues2D, become a
+			// grid; other arrays are not supported.
+			args[i], _ = decodeGrid(v, false)
+		default:
+			args[i] = variantValue(v)
+		}
+	}
+	return args
+}
+
+// derefVariant returns the value a by-reference argument points to, so that
+// it can be decoded like one passed by value. Other arguments are returned
+// as they are.
+func derefVariant(v *ole.VARIANT) *ole.VARIANT {
+	if v.VT&ole.VT_BYREF == 0 || v.VT&ole.VT_ARRAY != 0 {
+		return v
+	}
+	p := *(*unsafe.Pointer)(unsafe.Pointer(&v.Val))
+	out := &ole.VARIANT{VT: v.VT &^ ole.VT_BYREF}
+	switch out.VT {
+	case ole.VT_VARIANT:
+		return (*ole.VARIANT)(p)
+	case ole.VT_I1, ole.VT_UI1:
+		out.Val = int64(*(*uint8)(p))
+	case ole.VT_I2, ole.VT_UI2, ole.VT_BOOL:
+		out.Val = int64(*(*uint16)(p))
+	case ole.VT_I4, ole.VT_UI4, ole.VT_R4, ole.VT_ERROR:
+		out.Val = int64(*(*uint32)(p))
+	case ole.VT_I8, ole.VT_UI8, ole.VT_R8, ole.VT_DATE, ole.VT_CY:
+		out.Val = *(*int64)(p)
+	case ole.VT_BSTR, ole.VT_DISPATCH, ole.VT_UNKNOWN:
+		out.Val = int64(*(*uintptr)(p))
+	default:
+		return v
+	}
+	return out
+}
+
+// resultVariant converts a value returned by a Dispatcher to a VARIANT owned
+// by the caller.
+func resultVariant(out interface{}) (ole.VARIANT, error) {
+	switch out := out.(type) {
+	case *goEnum:
+		return ole.NewVariant(ole.VT_UNKNOWN, int64(uintptr(unsafe.Pointer(out)))), nil
+	case Dispatcher:
+		return ole.NewVariant(ole.VT_DISPATCH, int64(uintptr(unsafe.Pointer(newGoDispatch(out))))), nil
+	case *ole.IDispatch:
+		if out == nil {
+			return ole.NewVariant(ole.VT_DISPATCH, 0), nil
+		}
+		out.AddRef()
+		return ole.NewVariant(ole.VT_DISPATCH, int64(uintptr(unsafe.Pointer(out)))), nil
+	case []interface{}:
+		v, err := newSliceVariant(out)
+		if err != nil {
+			return ole.VARIANT{}, err
+		}
+		return *v, nil
+	case [][]interface{}:
+		v, err := newGridVariant(out)
+		if err != nil {
+			return ole.VARIANT{}, err
+		}
+		return *v, nil
+	default:
+		return toVariant(out)
+	}
+}
+
+// setException reports err to the caller of Invoke through EXCEPINFO. An
+// error carrying an HRESULT, such as an *ole.OleError, is reported with that
+// code, and with E_FAIL otherwise.
+func setException(info *excepInfo, err error) uintptr {
+	scode := uint32(ole.E_FAIL)
+	if code, ok := HRESULT(err); ok {
+		scode = code
+	}
+	if info == nil {
+		return uintptr(scode)
+	}
+	*info = excepInfo{
+		bstrSource:      ole.SysAllocString("sugar"),
+		bstrDescription: ole.SysAllocString(err.Error()),
+		scode:           scode,
+	}
+	return dispEException
+}
+
+func goEnumQueryInterface(e *goEnum, iid *ole.GUID, ppv **goEnum) uintptr {
+	if ole.IsEqualGUID(iid, ole.IID_IUnknown) || ole.IsEqualGUID(iid, ole.IID_IEnumVariant) {
+		goEnumAddRef(e)
+		*ppv = e
+		return ole.S_OK
+	}
+	*ppv = nil
+	return ole.E_NOINTERFACE
+}
+
+func goEnumAddRef(e *goEnum) uintptr {
+	return uintptr(atomic.AddInt32(&e.ref, 1))
+}
+
+func goEnumRelease(e *goEnum) uintptr {
+	n := atomic.AddInt32(&e.ref, -1)
+	if n == 0 {
+		liveGoObjects.Delete(uintptr(unsafe.Pointer(e)))
+	}
+	return uintptr(n)
+}
+
+func goEnumNext(e *goEnum, celt uint32, rgVar *ole.VARIANT, fetched *uint32) uintptr {
+	var n uint32
+	if celt > 0 {
+		out := unsafe.Slice(rgVar, celt)
+		for n < celt && e.pos < len(e.items) {
+			v, err := resultVariant(e.items[e.pos])
+			if err != nil {
+				break
+			}
+			out[n] = v
+			n++
+			e.pos++
+		}
+	}
+	if fetched != nil {
+		*fetched = n
+	}
+	if n < celt {
+		return sFalse
+	}
+	return ole.S_OK
+}
+
+func goEnumSkip(e *goEnum, celt uint32) uintptr {
+	e.pos += int(celt)
+	if e.pos > len(e.items) {
+		e.pos = len(e.items)
+		return sFalse
+	}
+	return ole.S_OK
+}
+
+func goEnumReset(e *goEnum) uintptr {
+	e.pos = 0
+	return ole.S_OK
+}
+
+func goEnumClone(e *goEnum, ppEnum **goEnum) uintptr {
+	clone := newGoEnum(e.items)
+	clone.pos = e.pos
+	*ppEnum = clone
+	return ole.S_OK
+}
@@ -0,0 +1,80 @@
+//go:build windows
+
+package sugar
+
+import (
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/go-ole/go-ole"
+)
+
+// CacheDispIDs enables or disables caching of member DISPIDs, so that repeated
+// calls to the same member of the same object skip GetIDsOfNames. Nested
+// Contexts share the cache of the Context they inherit it from.
+//
+// Entries are keyed by object identity, which COM only guarantees while the
+// object is alive. The cache therefore drops an object's entries whenever a
+// chain holding it is released; objects kept alive outside of chains (see
+// Store) must not be released while chains on the same object are in use.
+func CacheDispIDs(enabled bool) ContextOption {
+	return func(o *contextOptions) {
+		switch {
+		case !enabled:
+			o.dispids = nil
+		case o.dispids == nil:
+			o.dispids = &dispidCache{ids: make(map[dispidKey]int32)}
+		}
+	}
+}
+
+type dispidKey struct {
+	disp   uintptr
+	member string
+}
+
+type dispidCache struct {
+	mu  sync.Mutex
+	ids map[dispidKey]int32
+}
+
+func (d *dispidCache) lookup(disp *ole.IDispatch, member string) (int32, error) {
+	key := dispidKey{uintptr(unsafe.Pointer(disp)), strings.ToLower(member)}
+	d.mu.Lock()
+	id, ok := d.ids[key]
+	d.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	id, err := disp.GetSingleIDOfName(member)
+	if err != nil {
+		return 0, err
+	}
+	d.mu.Lock()
+	d.ids[key] = id
+	d.mu.Unlock()
+	return id, nil
+}
+
+// forget drops every entry for disp.
+func (d *dispidCache) forget(disp *ole.IDispatch) {
+	p := uintptr(unsafe.Pointer(disp))
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key := range d.ids {
+		if key.disp == p {
+			delete(d.ids, key)
+		}
+	}
+}
+
+// dispID resolves member on the chain's object, through the Context's cache
+// when one is enabled.
+func (c *chain) dispID(member string) (int32, error) {
+	if cache := c.options().dispids; cache != nil {
+		return cache.lookup(c.disp, member)
+	}
+	return c.disp.GetSingleIDOfName(member)
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package sugar
+
+// GetEnum reads an enum-valued property, such as Worksheet.Visible, as the
+// typed constant T.
+func GetEnum[T ~int32](ch Chain, prop string) (T, error) {
+	v, err := ch.Get(prop).Value()
+	if err != nil {
+		return 0, err
+	}
+	return coerce[T](v)
+}
+
+// PutEnum sets an enum-valued property from the typed constant v, sent as its
+// underlying 32-bit integer.
+func PutEnum[T ~int32](ch Chain, prop string, v T) Chain {
+	return ch.Put(prop, int32(v))
+}
@@ -0,0 +1,151 @@
+//go:build windows
+
+package sugar
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-ole/go-ole"
+)
+
+// ErrNotFound is returned when a search such as Find matches nothing.
+var ErrNotFound = errors.New("not found")
+
+// CellError represents a VT_ERROR VARIANT, such as an Excel cell holding #N/A.
+// Value and the readers of arrays return it for VT_ERROR results when the
+// Context was created with ErrorsAsErrors; Chain.CellError returns it either
+// way.
+type CellError struct {
+	// Code is the SCODE carried by the VARIANT. For Excel cell errors it is
+	// 0x800A0000 plus Excel's xlErr value in the low word: 0x800A07FA (2042)
+	// for #N/A, 0x800A07D7 (2007) for #DIV/0!.
+	Code uint32
+}
+
+// cellErrorNames maps the xlErr values found in the low word of
+// CellError.Code to the names Excel displays.
+var cellErrorNames = map[uint32]string{
+	2000: "#NULL!",
+	2007: "#DIV/0!",
+	2015: "#VALUE!",
+	2023: "#REF!",
+	2029: "#NAME?",
+	2036: "#NUM!",
+	2042: "#N/A",
+}
+
+func (e *CellError) Error() string {
+	if name, ok := cellErrorNames[e.Code&0xFFFF]; ok {
+		return fmt.Sprintf("cell error %s (0x%08X)", name, e.Code)
+	}
+	return fmt.Sprintf("cell error 0x%08X", e.Code)
+}
+
+// PanicError is returned by Runner.Do when the function it runs panics.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n\n%s", e.Value, e.Stack)
+}
+
+// Unwrap returns the panic value if it is an error, such as a runtime error
+// from a nil dereference.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Operation kinds recorded in ComError.
+const (
+	OpGet    = "Get"
+	OpCall   = "Call"
+	OpPut    = "Put"
+	OpPutRef = "PutRef"
+	OpAccess = "Access"
+	OpOn     = "On"
+)
+
+// ComError describes a failed COM invocation made by a Chain.
+type ComError struct {
+	// Op is the kind of operation, such as OpGet or OpCall.
+	Op string
+	// Member is the name of the property or method that was invoked.
+	Member string
+	// Code is the HRESULT, or 0 if the underlying error did not carry one.
+	Code uint32
+	// Err is the underlying error, usually an *ole.OleError.
+	Err error
+	// Suggestion is a similarly named member of the object when Member was
+	// not found. It is only filled in for Contexts created with
+	// SuggestMembers.
+	Suggestion string
+}
+
+func (e *ComError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("%s %s: %v (did you mean %s?)", e.Op, e.Member, e.Err, e.Suggestion)
+	}
+	return fmt.Sprintf("%s %s: %v", e.Op, e.Member, e.Err)
+}
+
+func (e *ComError) Unwrap() error {
+	return e.Err
+}
+
+func wrapErr(op, member string, err error) error {
+	if err == nil {
+		return nil
+	}
+	code, _ := HRESULT(err)
+	return &ComError{Op: op, Member: member, Code: code, Err: err}
+}
+
+// HRESULT extracts the HRESULT carried by err, if any. Use ExceptionCode for
+// the codes servers such as Excel raise with exceptions.
+func HRESULT(err error) (uint32, bool) {
+	var comErr *ComError
+	if errors.As(err, &comErr) && comErr.Code != 0 {
+		return comErr.Code, true
+	}
+	var oleErr *ole.OleError
+	if errors.As(err, &oleErr) {
+		return uint32(oleErr.Code()), true
+	}
+	return 0, false
+}
+
+// ExceptionCode returns the code the server reported for err: the SCODE of the
+// exception for DISP_E_EXCEPTION, and the HRESULT otherwise. Excel raises its
+// errors, such as 0x800A03EC, as exceptions, so HRESULT only reports
+// DISP_E_EXCEPTION (0x80020009) for them.
+func ExceptionCode(err error) (uint32, bool) {
+	var oleErr *ole.OleError
+	if errors.As(err, &oleErr) && uint32(oleErr.Code()) == dispEException {
+		// Both go-ole's EXCEPINFO and exception report the code this way.
+		if info, ok := oleErr.SubError().(interface{ SCODE() uint32 }); ok && info.SCODE() != 0 {
+			return info.SCODE(), true
+		}
+	}
+	return HRESULT(err)
+}
+
+// HRESULTs reported when the server behind a proxy has gone away.
+var disconnectCodes = map[uint32]bool{
+	0x80010108: true, // RPC_E_DISCONNECTED
+	0x800706BA: true, // RPC_S_SERVER_UNAVAILABLE
+	0x800706BE: true, // RPC_S_CALL_FAILED
+	0x800401FD: true, // CO_E_OBJNOTCONNECTED
+}
+
+// IsDisconnected reports whether err indicates that the COM server behind an
+// object is no longer reachable, for example because the process exited.
+func IsDisconnected(err error) bool {
+	code, ok := HRESULT(err)
+	return ok && disconnectCodes[code]
+}
@@ -0,0 +1,255 @@
+//go:build windows
+
+package sugar
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+
+	"github.com/go-ole/go-ole"
+)
+
+// dispParams mirrors DISPPARAMS with a typed argument pointer.
+type dispParams struct {
+	rgvarg            *ole.VARIANT
+	rgdispidNamedArgs *int32
+	cArgs             uint32
+	cNamedArgs        uint32
+}
+
+// eventSink is an IDispatch implemented in Go that forwards one event of a
+// source interface to a handler. The vtbl field must come first.
+type eventSink struct {
+	vtbl    *[7]uintptr
+	ref     int32
+	iid     ole.GUID
+	dispid  int32
+	handler func(args []interface{}) error
+}
+
+var (
+	sinkVtblOnce sync.Once
+	sinkVtbl     [7]uintptr
+
+	// liveSinks keeps sinks reachable while the source holds references to
+	// them, since the garbage collector cannot see those.
+	liveSinks sync.Map
+)
+
+func newEventSink(iid ole.GUID, dispid int32, handler func(args []interface{}) error) *eventSink {
+	sinkVtblOnce.Do(func() {
+		sinkVtbl = [7]uintptr{
+			syscall.NewCallback(sinkQueryInterface),
+			syscall.NewCallback(sinkAddRef),
+			syscall.NewCallback(sinkRelease),
+			syscall.NewCallback(sinkGetTypeInfoCount),
+			syscall.NewCallback(sinkGetTypeInfo),
+			syscall.NewCallback(sinkGetIDsOfNames),
+			syscall.NewCallback(sinkInvoke),
+		}
+	})
+	s := &eventSink{vtbl: &sinkVtbl, iid: iid, dispid: dispid, handler: handler}
+	liveSinks.Store(s, struct{}{})
+	return s
+}
+
+func sinkQueryInterface(s *eventSink, iid *ole.GUID, ppv **eventSink) uintptr {
+	if ole.IsEqualGUID(iid, ole.IID_IUnknown) || ole.IsEqualGUID(iid, ole.IID_IDispatch) || ole.IsEqualGUID(iid, &s.iid) {
+		sinkAddRef(s)
+		*ppv = s
+		return ole.S_OK
+	}
+	*ppv = nil
+	return ole.E_NOINTERFACE
+}
+
+func sinkAddRef(s *eventSink) uintptr {
+	return uintptr(atomic.AddInt32(&s.ref, 1))
+}
+
+func sinkRelease(s *eventSink) uintptr {
+	n := atomic.AddInt32(&s.ref, -1)
+	if n == 0 {
+		liveSinks.Delete(s)
+	}
+	return uintptr(n)
+}
+
+func sinkGetTypeInfoCount(s *eventSink, count *uint32) uintptr {
+	*count = 0
+	return ole.S_OK
+}
+
+// Sources invoke sinks by DISPID, so names and type information are not needed.
+func sinkGetTypeInfo(s *eventSink, _, _ uintptr, _ *uintptr) uintptr {
+	return ole.E_NOTIMPL
+}
+
+func sinkGetIDsOfNames(s *eventSink, _ *ole.GUID, _ uintptr, _, _ uintptr, _ *int32) uintptr {
+	return ole.E_NOTIMPL
+}
+
+func sinkInvoke(s *eventSink, dispid int32, _ *ole.GUID, _, _ uintptr, params *dispParams, _ *ole.VARIANT, _ uintptr, _ *uint32) (hr uintptr) {
+	if dispid != s.dispid {
+		return ole.S_OK
+	}
+	defer func() {
+		// A panic must not unwind into the caller's COM runtime.
+		if recover() != nil {
+			hr = ole.E_FAIL
+		}
+	}()
+
+	var vars []ole.VARIANT
+	if params != nil && params.cArgs > 0 {
+		vars = unsafe.Slice(params.rgvarg, params.cArgs)
+	}
+	// Arguments arrive in reverse order.
+	args := make([]interface{}, len(vars))
+	var writeBack []func()
+	for i := range args {
+		v := &vars[len(vars)-1-i]
+		switch v.VT {
+		case ole.VT_DISPATCH:
+			// Only valid during the call; Fork keeps a reference.
+			args[i] = &chain{disp: v.ToIDispatch(), borrowed: true, thread: ownerThread()}
+		case ole.VT_BYREF | ole.VT_BOOL:
+			ref := *(**int16)(unsafe.Pointer(&v.Val))
+			b := *ref != 0
+			args[i] = &b
+			writeBack = append(writeBack, func() {
+				if b {
+					*ref = -1
+				} else {
+					*ref = 0
+				}
+			})
+		case ole.VT_BYREF | ole.VT_VARIANT:
+			args[i] = variantValue(*(**ole.VARIANT)(unsafe.Pointer(&v.Val)))
+		default:
+			args[i] = variantValue(v)
+		}
+	}
+
+	err := s.handler(args)
+	for _, fn := range writeBack {
+		fn()
+	}
+	if err != nil {
+		return ole.E_FAIL
+	}
+	return ole.S_OK
+}
+
+// comCall invokes the method at index i of the vtable of the COM object obj.
+func comCall(obj unsafe.Pointer, i int, args ...uintptr) uintptr {
+	vtbl := *(*unsafe.Pointer)(obj)
+	fn := *(*uintptr)(unsafe.Add(vtbl, i*int(unsafe.Sizeof(uintptr(0)))))
+	hr, _, _ := syscall.SyscallN(fn, append([]uintptr{uintptr(obj)}, args...)...)
+	return hr
+}
+
+func releaseUnknown(obj unsafe.Pointer) {
+	(*ole.IUnknown)(obj).Release()
+}
+
+// Vtable indices beyond IUnknown's three methods.
+const (
+	vtblEnumConnectionPoints   = 3 // IConnectionPointContainer
+	vtblEnumNext               = 3 // IEnumConnectionPoints
+	vtblGetConnectionInterface = 3 // IConnectionPoint
+	vtblGetTypeInfoOfGUID      = 6 // ITypeLib
+	vtblGetIDsOfNames          = 10
+	vtblGetContainingTypeLib   = 18 // ITypeInfo
+)
+
+// findEvent looks for the connection point whose source interface has a member
+// named event, using the type library of disp to resolve the names.
+func findEvent(disp *ole.IDispatch, event string) (*ole.IConnectionPoint, ole.GUID, int32, error) {
+	var none ole.GUID
+	unk, err := disp.QueryInterface(ole.IID_IConnectionPointContainer)
+	if err != nil {
+		return nil, none, 0, fmt.Errorf("object does not raise events: %w", err)
+	}
+	container := unsafe.Pointer(unk)
+	defer releaseUnknown(container)
+
+	info, err := disp.GetTypeInfo()
+	if err != nil {
+		return nil, none, 0, fmt.Errorf("object has no type information: %w", err)
+	}
+	defer info.Release()
+	var lib unsafe.Pointer
+	var index uint32
+	if hr := comCall(unsafe.Pointer(info), vtblGetContainingTypeLib, uintptr(unsafe.Pointer(&lib)), uintptr(unsafe.Pointer(&index))); hr != 0 {
+		return nil, none, 0, ole.NewError(hr)
+	}
+	defer releaseUnknown(lib)
+
+	var enum unsafe.Pointer
+	if hr := comCall(container, vtblEnumConnectionPoints, uintptr(unsafe.Pointer(&enum))); hr != 0 {
+		return nil, none, 0, ole.NewError(hr)
+	}
+	defer releaseUnknown(enum)
+
+	name, err := syscall.UTF16PtrFromString(event)
+	if err != nil {
+		return nil, none, 0, err
+	}
+	for {
+		var cp unsafe.Pointer
+		var fetched uint32
+		if hr := comCall(enum, vtblEnumNext, 1, uintptr(unsafe.Pointer(&cp)), uintptr(unsafe.Pointer(&fetched))); hr != 0 || fetched == 0 {
+			break
+		}
+		var iid ole.GUID
+		var source unsafe.Pointer
+		if comCall(cp, vtblGetConnectionInterface, uintptr(unsafe.Pointer(&iid))) != 0 ||
+			comCall(lib, vtblGetTypeInfoOfGUID, uintptr(unsafe.Pointer(&iid)), uintptr(unsafe.Pointer(&source))) != 0 {
+			releaseUnknown(cp)
+			continue
+		}
+		var dispid int32
+		hr := comCall(source, vtblGetIDsOfNames, uintptr(unsafe.Pointer(&name)), 1, uintptr(unsafe.Pointer(&dispid)))
+		releaseUnknown(source)
+		if hr == 0 {
+			return (*ole.IConnectionPoint)(cp), iid, dispid, nil
+		}
+		releaseUnknown(cp)
+	}
+	return nil, none, 0, fmt.Errorf("object has no event named %q", event)
+}
+
+// On subscribes handler to an event of the object.
+func (c *chain) On(event string, handler func(args []interface{}) error) (func(), error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.disp == nil {
+		return nil, errors.New("dispatch is nil")
+	}
+
+	cp, iid, dispid, err := findEvent(c.disp, event)
+	if err != nil {
+		return nil, wrapErr(OpOn, event, err)
+	}
+	sink := newEventSink(iid, dispid, handler)
+	cookie, err := cp.Advise((*ole.IUnknown)(unsafe.Pointer(sink)))
+	if err != nil {
+		liveSinks.Delete(sink)
+		cp.Release()
+		return nil, wrapErr(OpOn, event, err)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cp.Unadvise(cookie)
+			cp.Release()
+		})
+	}, nil
+}
@@ -0,0 +1,50 @@
+//go:build windows
+
+package excel
+
+import (
+	"errors"
+
+	"github.com/xll-gen/sugar"
+)
+
+// Collection is an Excel collection, such as Workbooks or Worksheets, whose
+// items are wrapped as T. It carries the Count, Item and iteration methods
+// shared by the collection wrappers of this package; NewCollection wraps
+// collections that have no wrapper of their own, such as Names or Charts.
+type Collection[T any] struct {
+	sugar.Chain
+	wrap func(item sugar.Chain) T
+}
+
+// NewCollection wraps the collection held by ch, using wrap to turn each item
+// into a T.
+//
+//	names := excel.NewCollection(wb.Get("Names"), func(item sugar.Chain) sugar.Chain { return item })
+func NewCollection[T any](ch sugar.Chain, wrap func(item sugar.Chain) T) *Collection[T] {
+	return &Collection[T]{Chain: ch, wrap: wrap}
+}
+
+// Count returns the number of items in the collection.
+func (c *Collection[T]) Count() (int, error) {
+	n, err := c.Get("Count").GetInt()
+	return int(n), err
+}
+
+// Item returns a specific item by its 1-based index or its name.
+func (c *Collection[T]) Item(index interface{}) T {
+	return c.wrap(c.Get("Item", index))
+}
+
+// Each calls fn for each item in order. Returning sugar.ErrForEachBreak from
+// fn stops early without an error; any other error stops and is returned.
+// Items are released once fn returns, as with Chain.ForEach.
+func (c *Collection[T]) Each(fn func(item T) error) error {
+	err := c.ForEach(func(item sugar.Chain) error {
+		return fn(c.wrap(item))
+	}).Err()
+	if errors.Is(err, sugar.ErrForEachBreak) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+//go:build windows
+
+package excel
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xll-gen/sugar"
+)
+
+// CSVOptions configures Range.ToCSV. The zero value writes comma-separated
+// fields with LF line endings, quoting only where needed.
+type CSVOptions struct {
+	// Comma is the field delimiter. Defaults to ','.
+	Comma rune
+	// UseCRLF ends lines with \r\n instead of \n.
+	UseCRLF bool
+	// QuoteAll quotes every field, not just those that need it.
+	QuoteAll bool
+	// Raw reads Value2, so dates are written as serial numbers instead of
+	// being formatted with DateLayout.
+	Raw bool
+	// DateLayout is the time.Format layout for date cells. Defaults to
+	// "2006-01-02 15:04:05".
+	DateLayout string
+}
+
+// ToCSV reads the range in one call and writes it to w as CSV. Empty cells
+// become empty fields.
+func (r *excelRange) ToCSV(w io.Writer, opts CSVOptions) error {
+	var vopts []sugar.ValuesOption
+	if opts.Raw {
+		vopts = append(vopts, sugar.UseValue2())
+	}
+	grid, err := r.Values2D(vopts...)
+	if err != nil {
+		return err
+	}
+
+	if opts.Comma == 0 {
+		opts.Comma = ','
+	}
+	if opts.DateLayout == "" {
+		opts.DateLayout = "2006-01-02 15:04:05"
+	}
+
+	records := make([][]string, len(grid))
+	for i, row := range grid {
+		records[i] = make([]string, len(row))
+		for j, cell := range row {
+			records[i][j] = formatCSVField(cell, opts.DateLayout)
+		}
+	}
+
+	if opts.QuoteAll {
+		return writeQuotedCSV(w, records, opts)
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.Comma
+	cw.UseCRLF = opts.UseCRLF
+	return cw.WriteAll(records)
+}
+
+func formatCSVField(v interface{}, dateLayout string) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case time.Time:
+		return v.Format(dateLayout)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// writeQuotedCSV writes records with every field quoted, which encoding/csv
+// does not support.
+func writeQuotedCSV(w io.Writer, records [][]string, opts CSVOptions) error {
+	eol := "\n"
+	if opts.UseCRLF {
+		eol = "\r\n"
+	}
+	var b strings.Builder
+	for _, record := range records {
+		for i, field := range record {
+			if i > 0 {
+				b.WriteRune(opts.Comma)
+			}
+			b.WriteByte('"')
+			b.WriteString(strings.ReplaceAll(field, `"`, `""`))
+			b.WriteByte('"')
+		}
+		b.WriteString(eol)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -3,9 +3,23 @@
 package excel
 
 import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
 	"github.com/xll-gen/sugar"
 )
 
+// CalculationMode is the recalculation mode of Excel (XlCalculation).
+type CalculationMode int
+
+const (
+	CalculationAutomatic     CalculationMode = -4105
+	CalculationManual        CalculationMode = -4135
+	CalculationSemiAutomatic CalculationMode = 2
+)
+
 // Application represents the Excel.Application object.
 // It is the root of the Excel object model.
 type Application interface {
@@ -14,36 +28,305 @@ type Application interface {
 	Workbooks() Workbooks
 	// ActiveWorkbook returns the workbook that is currently active.
 	ActiveWorkbook() Workbook
+	// Hwnd returns the handle of the main Excel window.
+	Hwnd() (uintptr, error)
+	// ProcessID returns the ID of the Excel process, for use with
+	// GetApplicationByPID.
+	ProcessID() (int, error)
+	// Selection returns the currently selected range.
+	Selection() Range
+	// AddIns returns the collection of add-ins known to Excel.
+	AddIns() AddIns
+	// SetFullScreen turns full-screen mode (DisplayFullScreen) on or off.
+	SetFullScreen(on bool) Application
+	// ShowRibbon shows or hides the ribbon, or the worksheet menu bar in
+	// versions of Excel that predate the ribbon.
+	ShowRibbon(show bool) error
+	// SetScreenUpdating turns screen redrawing on or off.
+	SetScreenUpdating(on bool) Application
+	// SetCalculation sets the recalculation mode.
+	SetCalculation(mode CalculationMode) Application
+	// SetEnableEvents turns event handling (workbook and sheet macros) on or
+	// off.
+	//
+	// ScreenUpdating, Calculation and EnableEvents are application-wide and
+	// outlive the automation session when attached to a user's Excel, so
+	// restore them on teardown:
+	//
+	//	app.SetScreenUpdating(false).SetCalculation(excel.CalculationManual)
+	//	defer app.SetScreenUpdating(true).SetCalculation(excel.CalculationAutomatic)
+	SetEnableEvents(on bool) Application
+	// Reconnect re-attaches to Excel the way the Application was first
+	// obtained and swaps the new connection into this Application in place: an
+	// Application from GetApplicationByPID attaches to the same process again,
+	// any other to the running instance registered in the Running Object
+	// Table. Only the Application itself survives a reconnect: Workbooks,
+	// Worksheets, Ranges and other wrappers derived from it before stay bound
+	// to the old connection, keep failing, and must be fetched again.
+	Reconnect() error
+	// WithReconnect runs fn and, if it fails because the connection to Excel
+	// was lost (see sugar.IsDisconnected), reconnects and runs fn once more.
+	// fn must therefore fetch every object it uses from app rather than
+	// capture wrappers obtained outside it, which stay dead after a reconnect.
+	WithReconnect(fn func(app Application) error) error
+	// Run runs a VBA macro, such as "Module1.Refresh" or
+	// "'Book1.xlsm'!Refresh", with up to 30 arguments and returns its return
+	// value. Chain arguments are passed as the objects they hold. A macro
+	// returning an object yields a sugar.Chain. Running fails if the macro
+	// does not exist, its project is protected, or macros are disabled.
+	Run(macro string, args ...interface{}) (interface{}, error)
+	// WorksheetFunction returns Excel's built-in worksheet functions, such as
+	// Sum and VLookup, for use from Go.
+	WorksheetFunction() WorksheetFunction
 	// Quit quits the Excel application.
 	Quit() error
 }
 
 type application struct {
 	sugar.Chain
+	ctx sugar.Context
+	// pid is the process the application was attached to with
+	// GetApplicationByPID, which Reconnect attaches to again, or 0.
+	pid int
 }
 
 func (a *application) Workbooks() Workbooks {
-	return &workbooks{a.Get("Workbooks")}
+	return newWorkbooks(a.Get("Workbooks"))
 }
 
 func (a *application) ActiveWorkbook() Workbook {
 	return &workbook{a.Get("ActiveWorkbook")}
 }
 
+func (a *application) Hwnd() (uintptr, error) {
+	n, err := a.Get("Hwnd").GetInt()
+	return uintptr(n), err
+}
+
+func (a *application) ProcessID() (int, error) {
+	hwnd, err := a.Hwnd()
+	if err != nil {
+		return 0, err
+	}
+	return sugar.WindowProcessID(hwnd)
+}
+
+func (a *application) Selection() Range {
+	return &excelRange{a.Get("Selection")}
+}
+
+func (a *application) AddIns() AddIns {
+	return newAddIns(a.Get("AddIns"))
+}
+
+func (a *application) SetFullScreen(on bool) Application {
+	return &application{Chain: a.Put("DisplayFullScreen", on), ctx: a.ctx, pid: a.pid}
+}
+
+func (a *application) SetScreenUpdating(on bool) Application {
+	return &application{Chain: a.Put("ScreenUpdating", on), ctx: a.ctx, pid: a.pid}
+}
+
+func (a *application) SetCalculation(mode CalculationMode) Application {
+	return &application{Chain: a.Put("Calculation", int(mode)), ctx: a.ctx, pid: a.pid}
+}
+
+func (a *application) SetEnableEvents(on bool) Application {
+	return &application{Chain: a.Put("EnableEvents", on), ctx: a.ctx, pid: a.pid}
+}
+
+func (a *application) ShowRibbon(show bool) error {
+	version, err := a.Get("Version").GetString()
+	if err != nil {
+		return err
+	}
+	// Excel 2007 (12.0) introduced the ribbon; it can only be toggled through
+	// the XLM SHOW.TOOLBAR function.
+	major, _, _ := strings.Cut(version, ".")
+	if n, err := strconv.Atoi(major); err == nil && n < 12 {
+		return a.Get("CommandBars", "Worksheet Menu Bar").Put("Enabled", show).Err()
+	}
+	macro := fmt.Sprintf(`SHOW.TOOLBAR("Ribbon",%t)`, show)
+	return a.Call("ExecuteExcel4Macro", macro).Err()
+}
+
+func (a *application) Reconnect() error {
+	var active sugar.Chain
+	if a.pid != 0 {
+		active = a.ctx.GetActiveByPID("Excel.Application", a.pid)
+	} else {
+		active = a.ctx.GetActive("Excel.Application")
+	}
+	if err := active.Err(); err != nil {
+		return err
+	}
+	old := a.Chain
+	a.Chain = active
+	old.Release()
+	return nil
+}
+
+func (a *application) WithReconnect(fn func(app Application) error) error {
+	err := fn(a)
+	if !sugar.IsDisconnected(err) {
+		return err
+	}
+	if rerr := a.Reconnect(); rerr != nil {
+		return fmt.Errorf("excel: reconnect failed: %w (after %v)", rerr, err)
+	}
+	return fn(a)
+}
+
+// maxRunArgs is the number of macro arguments Application.Run accepts.
+const maxRunArgs = 30
+
+func (a *application) Run(macro string, args ...interface{}) (interface{}, error) {
+	if len(args) > maxRunArgs {
+		return nil, fmt.Errorf("excel: macro %q: %d arguments exceed the limit of %d", macro, len(args), maxRunArgs)
+	}
+	res := a.Call("Run", append([]interface{}{macro}, args...)...)
+	if err := res.Err(); err != nil {
+		return nil, fmt.Errorf("excel: running macro %q: %w", macro, err)
+	}
+	if res.IsDispatch() {
+		return res, nil
+	}
+	return res.Value()
+}
+
+func (a *application) WorksheetFunction() WorksheetFunction {
+	return &worksheetFunction{a.Get("WorksheetFunction")}
+}
+
+// WorksheetFunction calls Excel's built-in worksheet functions.
+type WorksheetFunction interface {
+	// Call calls the named function, such as "Sum" or "VLookup", and returns
+	// its result. Range arguments are passed as the objects they hold. An
+	// error value such as #N/A is returned as a *sugar.CellError. Some
+	// functions report failures as a COM error instead, which is returned as
+	// a *sugar.ComError.
+	Call(name string, args ...interface{}) (interface{}, error)
+	// Err returns the error from fetching the WorksheetFunction object.
+	Err() error
+}
+
+type worksheetFunction struct {
+	obj sugar.Chain
+}
+
+func (w *worksheetFunction) Call(name string, args ...interface{}) (interface{}, error) {
+	res := w.obj.Call(name, args...)
+	if err := res.Err(); err != nil {
+		return nil, fmt.Errorf("excel: worksheet function %s: %w", name, err)
+	}
+	if cellErr, ok := res.CellError(); ok {
+		return nil, cellErr
+	}
+	if res.IsDispatch() {
+		return res, nil
+	}
+	return res.Value()
+}
+
+func (w *worksheetFunction) Err() error {
+	return w.obj.Err()
+}
+
 func (a *application) Quit() error {
 	return a.Call("Quit").Err()
 }
 
 // NewApplication creates a new Excel instance.
 func NewApplication(ctx sugar.Context) Application {
-	return &application{ctx.Create("Excel.Application")}
+	return &application{Chain: ctx.Create("Excel.Application"), ctx: ctx}
 }
 
 // GetApplication attaches to a running Excel instance.
 func GetApplication(ctx sugar.Context) Application {
-	return &application{ctx.GetActive("Excel.Application")}
+	return &application{Chain: ctx.GetActive("Excel.Application"), ctx: ctx}
 }
 
+// GetApplicationByPID attaches to the Excel instance running in the given
+// process. The instance needs at least one open workbook window.
+func GetApplicationByPID(ctx sugar.Context, pid int) Application {
+	return &application{Chain: ctx.GetActiveByPID("Excel.Application", pid), ctx: ctx, pid: pid}
+}
+
+// AddIns represents the AddIns collection.
+type AddIns interface {
+	sugar.Chain
+	// Item returns a specific add-in by index or title.
+	Item(index interface{}) AddIn
+	// Count returns the number of add-ins in the collection.
+	Count() (int, error)
+	// EnsureInstalled finds the add-in by title or file name and installs it
+	// if it is not installed yet. Excel usually needs an open workbook for this.
+	EnsureInstalled(name string) error
+}
+
+type addIns struct {
+	*Collection[AddIn]
+}
+
+func newAddIns(ch sugar.Chain) *addIns {
+	return &addIns{NewCollection(ch, func(item sugar.Chain) AddIn { return &addIn{item} })}
+}
+
+func (a *addIns) EnsureInstalled(name string) error {
+	item := a.Item(name)
+	if item.Err() != nil {
+		item = a.find(name)
+		if item == nil {
+			return fmt.Errorf("excel: add-in %q not found", name)
+		}
+	}
+
+	installed, err := item.Installed()
+	if err != nil {
+		return err
+	}
+	if installed {
+		return nil
+	}
+	return item.SetInstalled(true).Err()
+}
+
+// find looks up an add-in whose Title or Name matches name case-insensitively.
+func (a *addIns) find(name string) AddIn {
+	var found AddIn
+	a.ForEach(func(item sugar.Chain) error {
+		for _, prop := range []string{"Title", "Name"} {
+			s, err := item.Get(prop).GetString()
+			if err == nil && strings.EqualFold(s, name) {
+				found = &addIn{item.Fork()}
+				return sugar.ErrForEachBreak
+			}
+		}
+		return nil
+	})
+	return found
+}
+
+// AddIn represents a single AddIn object.
+type AddIn interface {
+	sugar.Chain
+	// Installed reports whether the add-in is installed.
+	Installed() (bool, error)
+	// SetInstalled installs or uninstalls the add-in.
+	SetInstalled(installed bool) AddIn
+}
+
+type addIn struct {
+	sugar.Chain
+}
+
+func (a *addIn) Installed() (bool, error) {
+	return a.Get("Installed").GetBool()
+}
+
+func (a *addIn) SetInstalled(installed bool) AddIn {
+	return &addIn{a.Put("Installed", installed)}
+}
 
 // Workbooks represents the Workbooks collection.
 type Workbooks interface {
@@ -52,18 +335,24 @@ type Workbooks interface {
 	Add() Workbook
 	// Item returns a specific workbook by index or name.
 	Item(index interface{}) Workbook
+	// Open opens the workbook at path.
+	Open(path string, opts ...OpenOption) Workbook
 }
 
 type workbooks struct {
-	sugar.Chain
+	*Collection[Workbook]
+}
+
+func newWorkbooks(ch sugar.Chain) *workbooks {
+	return &workbooks{NewCollection(ch, func(item sugar.Chain) Workbook { return &workbook{item} })}
 }
 
 func (w *workbooks) Add() Workbook {
 	return &workbook{w.Call("Add")}
 }
 
-func (w *workbooks) Item(index interface{}) Workbook {
-	return &workbook{w.Get("Item", index)}
+func (w *workbooks) Open(path string, opts ...OpenOption) Workbook {
+	return &workbook{openWorkbook(w, path, opts)}
 }
 
 // Workbook represents a Workbook object.
@@ -73,8 +362,18 @@ type Workbook interface {
 	Worksheets() Worksheets
 	// ActiveSheet returns the worksheet that is currently active.
 	ActiveSheet() Worksheet
+	// SheetCount returns the number of worksheets in the workbook.
+	SheetCount() (int, error)
+	// ForEachSheet calls fn for each worksheet in order. Returning
+	// sugar.ErrForEachBreak from fn stops the loop without an error; any other
+	// error stops it and is returned.
+	ForEachSheet(fn func(sheet Worksheet) error) error
 	// Save saves the workbook.
 	Save() error
+	// SaveAs saves the workbook to path, optionally in another format.
+	SaveAs(path string, opts ...SaveOption) error
+	// ExportPDF writes the workbook to a PDF file.
+	ExportPDF(path string, opts ...PDFOption) error
 	// Close closes the workbook.
 	Close() error
 }
@@ -84,17 +383,33 @@ type workbook struct {
 }
 
 func (w *workbook) Worksheets() Worksheets {
-	return &worksheets{w.Get("Worksheets")}
+	return newWorksheets(w.Get("Worksheets"))
 }
 
 func (w *workbook) ActiveSheet() Worksheet {
 	return &worksheet{w.Get("ActiveSheet")}
 }
 
+func (w *workbook) SheetCount() (int, error) {
+	return newWorksheets(w.Get("Worksheets")).Count()
+}
+
+func (w *workbook) ForEachSheet(fn func(sheet Worksheet) error) error {
+	return newWorksheets(w.Get("Worksheets")).Each(fn)
+}
+
 func (w *workbook) Save() error {
 	return w.Call("Save").Err()
 }
 
+func (w *workbook) SaveAs(path string, opts ...SaveOption) error {
+	return saveAs(w, path, opts)
+}
+
+func (w *workbook) ExportPDF(path string, opts ...PDFOption) error {
+	return exportPDF(w, path, opts)
+}
+
 func (w *workbook) Close() error {
 	return w.Call("Close").Err()
 }
@@ -104,14 +419,23 @@ type Worksheets interface {
 	sugar.Chain
 	// Item returns a specific worksheet by index or name.
 	Item(index interface{}) Worksheet
+	// GetOrAdd returns the worksheet with the given name, adding and naming a
+	// new one if it does not exist.
+	GetOrAdd(name string) Worksheet
 }
 
 type worksheets struct {
-	sugar.Chain
+	*Collection[Worksheet]
 }
 
-func (w *worksheets) Item(index interface{}) Worksheet {
-	return &worksheet{w.Get("Item", index)}
+func newWorksheets(ch sugar.Chain) *worksheets {
+	return &worksheets{NewCollection(ch, func(item sugar.Chain) Worksheet { return &worksheet{item} })}
+}
+
+func (w *worksheets) GetOrAdd(name string) Worksheet {
+	return &worksheet{sugar.GetOrCreate(w, name, func(coll sugar.Chain) sugar.Chain {
+		return coll.Call("Add").Put("Name", name)
+	})}
 }
 
 // Worksheet represents a Worksheet object.
@@ -122,6 +446,31 @@ type Worksheet interface {
 	Range(cell1 interface{}, cell2 ...interface{}) Range
 	// Cells returns a Range object representing a single cell at (row, col).
 	Cells(row, col interface{}) Range
+	// RangeRC returns the Range spanning the 1-based cells (r1, c1) to (r2, c2),
+	// without building an A1 address.
+	RangeRC(r1, c1, r2, c2 int) Range
+	// PageSetup returns the print settings of the worksheet.
+	PageSetup() PageSetup
+	// ExportPDF writes the worksheet to a PDF file.
+	ExportPDF(path string, opts ...PDFOption) error
+	// ListObjects returns the tables of the worksheet.
+	ListObjects() ListObjects
+	// Name returns the name of the worksheet.
+	Name() (string, error)
+	// SetName renames the worksheet. Excel rejects names that are empty,
+	// longer than 31 characters, contain any of : \ / ? * [ ] or are already
+	// used in the workbook; the error is reported through Err.
+	SetName(name string) Worksheet
+	// Delete deletes the worksheet. Excel asks for confirmation first, so set
+	// DisplayAlerts to false beforehand when automating. A workbook must keep
+	// at least one visible sheet.
+	Delete() error
+	// Copy copies the worksheet before or after another sheet; pass nil for
+	// the one not used. With both nil, Excel copies it into a new workbook.
+	Copy(before, after Worksheet) error
+	// Move moves the worksheet before or after another sheet, like Copy.
+	// With both nil, Excel moves it into a new workbook.
+	Move(before, after Worksheet) error
 }
 
 type worksheet struct {
@@ -139,13 +488,134 @@ func (w *worksheet) Cells(row, col interface{}) Range {
 	return &excelRange{w.Get("Cells", row, col)}
 }
 
+func (w *worksheet) RangeRC(r1, c1, r2, c2 int) Range {
+	return w.Range(w.Cells(r1, c1), w.Cells(r2, c2))
+}
+
+func (w *worksheet) PageSetup() PageSetup {
+	return &pageSetup{w.Get("PageSetup")}
+}
+
+func (w *worksheet) ExportPDF(path string, opts ...PDFOption) error {
+	return exportPDF(w, path, opts)
+}
+
+func (w *worksheet) ListObjects() ListObjects {
+	return newListObjects(w.Get("ListObjects"))
+}
+
+func (w *worksheet) Name() (string, error) {
+	return w.Get("Name").GetString()
+}
+
+func (w *worksheet) SetName(name string) Worksheet {
+	return &worksheet{w.Put("Name", name)}
+}
+
+func (w *worksheet) Delete() error {
+	return w.Call("Delete").Err()
+}
+
+func (w *worksheet) Copy(before, after Worksheet) error {
+	return w.Call("Copy", placement(before, after)...).Err()
+}
+
+func (w *worksheet) Move(before, after Worksheet) error {
+	return w.Call("Move", placement(before, after)...).Err()
+}
+
+// placement builds the Before and After arguments of Copy and Move. Nil
+// sheets are passed as missing and trailing ones are dropped.
+func placement(before, after Worksheet) []interface{} {
+	params := []interface{}{sugar.Missing, sugar.Missing}
+	last := -1
+	if before != nil {
+		params[0], last = before, 0
+	}
+	if after != nil {
+		params[1], last = after, 1
+	}
+	return params[:last+1]
+}
+
+// RGB returns the color value Excel expects for the given red, green and blue
+// components (0-255). Excel stores colors as BGR, so RGB(255, 0, 0) is 0xFF.
+func RGB(r, g, b int) int {
+	return r&0xFF | (g&0xFF)<<8 | (b&0xFF)<<16
+}
+
+// XlDirection is the direction Range.End moves in.
+type XlDirection int
+
+const (
+	XlUp      XlDirection = -4162
+	XlDown    XlDirection = -4121
+	XlToLeft  XlDirection = -4159
+	XlToRight XlDirection = -4161
+)
+
 // Range represents a cell, a row, a column, or a selection of cells.
 type Range interface {
 	sugar.Chain
 	// SetValue sets the value for the entire range.
 	SetValue(value interface{}) Range
+	// SetValues writes a rectangular grid of values in a single COM call.
+	// A ragged grid is reported through Err.
+	SetValues(data [][]interface{}) Range
+	// CellValue reads the Value property of the range. Unlike Chain.Value,
+	// which returns the result of the last operation, it always queries the
+	// range.
+	CellValue() (interface{}, error)
+	// SetFormula sets the A1-style formula of the range.
+	SetFormula(formula string) Range
+	// Formula returns the A1-style formula of a single cell, or its constant
+	// value as text if it has no formula.
+	Formula() (string, error)
 	// Cells returns a Range object representing a single cell relative to this range.
 	Cells(row, col interface{}) Range
+	// Offset returns a range of the same size shifted by rows and cols.
+	Offset(rows, cols int) Range
+	// Resize returns a range with the same top-left cell and the given size.
+	Resize(rows, cols int) Range
+	// End returns the cell at the edge of the data region in direction, like
+	// pressing Ctrl+Arrow. The last used row of column A is
+	// sheet.Cells(rows, 1).End(excel.XlUp), where rows is the sheet's row count.
+	End(direction XlDirection) Range
+	// SetNumberFormat sets the number format code, such as "0.00" or
+	// "yyyy-mm-dd".
+	SetNumberFormat(format string) Range
+	// SetFontBold makes the font bold or regular.
+	SetFontBold(bold bool) Range
+	// SetFontColor sets the font color; see RGB.
+	SetFontColor(rgb int) Range
+	// SetInteriorColor sets the fill color of the cells; see RGB.
+	SetInteriorColor(rgb int) Range
+	// Merge merges the cells of the range into one, keeping only the value
+	// of the top-left cell. Excel asks before discarding other values unless
+	// DisplayAlerts is off.
+	Merge() Range
+	// UnMerge splits merged cells in the range back into single cells.
+	UnMerge() Range
+	// AutoFit sets the width of the range's columns to fit their contents.
+	AutoFit() Range
+	// Select activates the parent worksheet and selects the range.
+	Select() error
+	// ToCSV writes the values of the range to w as CSV.
+	ToCSV(w io.Writer, opts CSVOptions) error
+	// AddHyperlink links the range to address, showing text in its cells.
+	AddHyperlink(address, text string) Range
+	// Hyperlink returns the address and display text of the hyperlink of a
+	// single cell, or an error wrapping sugar.ErrNotFound if it has none.
+	Hyperlink() (address string, text string, err error)
+	// FindCell wraps Excel's Range.Find: it returns the first cell in the
+	// range matching what, searching by rows. If nothing matches it returns a
+	// nil Range and an error wrapping sugar.ErrNotFound. (Find itself is
+	// Chain.Find, which searches collections.)
+	FindCell(what interface{}, opts ...FindOption) (Range, error)
+	// Sort sorts the rows of the range by the column of key, which is a cell
+	// or column within the range. Every row is sorted, so leave header rows
+	// out of the range.
+	Sort(key Range, order SortOrder) error
 }
 
 type excelRange struct {
@@ -156,6 +626,112 @@ func (r *excelRange) SetValue(value interface{}) Range {
 	return &excelRange{r.Put("Value", value)}
 }
 
+func (r *excelRange) SetValues(data [][]interface{}) Range {
+	return &excelRange{r.SetValues2D(data)}
+}
+
+func (r *excelRange) CellValue() (interface{}, error) {
+	return r.Get("Value").Value()
+}
+
+func (r *excelRange) SetFormula(formula string) Range {
+	return &excelRange{r.Put("Formula", formula)}
+}
+
+func (r *excelRange) Formula() (string, error) {
+	return r.Get("Formula").GetString()
+}
+
 func (r *excelRange) Cells(row, col interface{}) Range {
 	return &excelRange{r.Get("Cells", row, col)}
 }
+
+func (r *excelRange) AddHyperlink(address, text string) Range {
+	link := r.Get("Hyperlinks").Call("Add", r, address, sugar.Missing, sugar.Missing, text)
+	if link.Err() != nil {
+		return &excelRange{link}
+	}
+	return r
+}
+
+func (r *excelRange) Hyperlink() (string, string, error) {
+	cells, err := r.Get("Count").GetInt()
+	if err != nil {
+		return "", "", err
+	}
+	if cells != 1 {
+		return "", "", fmt.Errorf("excel: Hyperlink needs a single cell, range has %d", cells)
+	}
+
+	links := r.Get("Hyperlinks")
+	n, err := links.Len()
+	if err != nil {
+		return "", "", err
+	}
+	if n == 0 {
+		return "", "", fmt.Errorf("excel: cell has no hyperlink: %w", sugar.ErrNotFound)
+	}
+	link := links.Index(1)
+	address, err := link.Get("Address").GetString()
+	if err != nil {
+		return "", "", err
+	}
+	text, err := link.Get("TextToDisplay").GetString()
+	return address, text, err
+}
+
+func (r *excelRange) Offset(rows, cols int) Range {
+	return &excelRange{r.Get("Offset", rows, cols)}
+}
+
+func (r *excelRange) Resize(rows, cols int) Range {
+	return &excelRange{r.Get("Resize", rows, cols)}
+}
+
+func (r *excelRange) End(direction XlDirection) Range {
+	return &excelRange{r.Get("End", int(direction))}
+}
+
+func (r *excelRange) SetNumberFormat(format string) Range {
+	return &excelRange{r.Put("NumberFormat", format)}
+}
+
+func (r *excelRange) SetFontBold(bold bool) Range {
+	return r.style(r.Get("Font").Put("Bold", bold))
+}
+
+func (r *excelRange) SetFontColor(rgb int) Range {
+	return r.style(r.Get("Font").Put("Color", rgb))
+}
+
+func (r *excelRange) SetInteriorColor(rgb int) Range {
+	return r.style(r.Get("Interior").Put("Color", rgb))
+}
+
+func (r *excelRange) Merge() Range {
+	return r.style(r.Call("Merge"))
+}
+
+func (r *excelRange) UnMerge() Range {
+	return r.style(r.Call("UnMerge"))
+}
+
+func (r *excelRange) AutoFit() Range {
+	return r.style(r.Get("Columns").Call("AutoFit"))
+}
+
+// style returns the range itself so sub-object setters keep chaining on it,
+// carrying over the error of result if the put failed.
+func (r *excelRange) style(result sugar.Chain) Range {
+	if err := result.Err(); err != nil {
+		return &excelRange{result}
+	}
+	return r
+}
+
+func (r *excelRange) Select() error {
+	if err := r.Get("Parent").Call("Activate").Err(); err != nil {
+		return err
+	}
+	return r.Call("Select").Err()
+}
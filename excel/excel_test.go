@@ -3,11 +3,80 @@
 package excel_test
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/go-ole/go-ole"
 	"github.com/xll-gen/sugar"
 	"github.com/xll-gen/sugar/excel"
 )
 
+func ExampleRange_SetValues() {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			return err
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := app.Workbooks().Add().ActiveSheet()
+		err := sheet.Range("A1:C3").SetValues([][]interface{}{
+			{"Name", "Qty", "Price"},
+			{"Apple", 3, 1.25},
+			{"Pear", 5, 0.8},
+		}).Err()
+		if err != nil {
+			fmt.Println("SetValues failed:", err)
+		}
+		return err
+	})
+}
+
+// This example deletes every worksheet except the active one.
+func ExampleWorksheet_Delete() {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			return err
+		}
+		// Turning DisplayAlerts off also keeps Delete from asking for
+		// confirmation.
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		wb := app.Workbooks().Add()
+		active, err := wb.ActiveSheet().Name()
+		if err != nil {
+			return err
+		}
+
+		// Collect the names first: deleting while enumerating skips sheets.
+		var others []string
+		err = wb.ForEachSheet(func(sheet excel.Worksheet) error {
+			name, err := sheet.Name()
+			if err == nil && name != active {
+				others = append(others, name)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		for _, name := range others {
+			if err := wb.Worksheets().Item(name).Delete(); err != nil {
+				fmt.Println("Delete failed:", err)
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func TestExcel_Package(t *testing.T) {
 	sugar.Do(func(ctx sugar.Context) error {
 		app := excel.NewApplication(ctx)
@@ -53,3 +122,1043 @@ func TestExcel_Package(t *testing.T) {
 		return nil
 	})
 }
+
+func TestExcel_AddIns(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		// Installing add-ins requires an open workbook.
+		app.Workbooks().Add()
+
+		addIns := app.AddIns()
+		if _, err := addIns.Count(); err != nil {
+			t.Fatalf("failed to count add-ins: %v", err)
+		}
+
+		const name = "Analysis ToolPak"
+		if err := addIns.Item(name).Err(); err != nil {
+			t.Skip("add-in not present:", name)
+			return nil
+		}
+
+		if err := addIns.EnsureInstalled(name); err != nil {
+			t.Fatalf("EnsureInstalled failed: %v", err)
+		}
+
+		installed, err := addIns.Item(name).Installed()
+		if err != nil {
+			t.Fatalf("failed to read Installed: %v", err)
+		}
+		if !installed {
+			t.Error("expected add-in to be installed")
+		}
+
+		if err := addIns.EnsureInstalled("No Such Add-In"); err == nil {
+			t.Error("expected error for missing add-in")
+		}
+		return nil
+	})
+}
+
+func TestExcel_Select(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		wb := app.Workbooks().Add()
+		rng := wb.ActiveSheet().Range("B2:C3")
+		if err := rng.Select(); err != nil {
+			t.Fatalf("Select failed: %v", err)
+		}
+
+		want, err := rng.Get("Address").Value()
+		if err != nil {
+			t.Fatalf("failed to get range address: %v", err)
+		}
+		got, err := app.Selection().Get("Address").Value()
+		if err != nil {
+			t.Fatalf("failed to get selection address: %v", err)
+		}
+		if got != want {
+			t.Errorf("expected selection %v, got %v", want, got)
+		}
+		return nil
+	})
+}
+
+func TestExcel_GetApplicationByPID(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		apps := []excel.Application{excel.NewApplication(ctx), excel.NewApplication(ctx)}
+		for _, app := range apps {
+			if err := app.Err(); err != nil {
+				t.Skip("Excel not installed:", err)
+				return nil
+			}
+			defer app.Put("DisplayAlerts", false).Call("Quit")
+			// A workbook window is required to reach the object model.
+			app.Workbooks().Add()
+		}
+
+		for i, app := range apps {
+			pid, err := app.ProcessID()
+			if err != nil {
+				t.Fatalf("app %d: failed to get process ID: %v", i, err)
+			}
+			bound := excel.GetApplicationByPID(ctx, pid)
+			if err := bound.Err(); err != nil {
+				t.Fatalf("app %d: GetApplicationByPID failed: %v", i, err)
+			}
+
+			want, _ := app.Hwnd()
+			got, err := bound.Hwnd()
+			if err != nil || got != want {
+				t.Errorf("app %d: expected Hwnd %v, got %v (%v)", i, want, got, err)
+			}
+		}
+
+		// Scripting.Dictionary is registered everywhere, but its type library
+		// does not describe Excel's Application.
+		pid, _ := apps[0].ProcessID()
+		if err := ctx.GetActiveByPID("Scripting.Dictionary", pid).Err(); err == nil {
+			t.Error("expected an error for a ProgID the process does not serve")
+		}
+		return nil
+	})
+}
+
+func TestExcel_Reconnect(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		// A visible instance with a workbook registers itself as the active one.
+		app.Put("Visible", true)
+		app.Workbooks().Add()
+		want, _ := app.Hwnd()
+
+		attempts := 0
+		err := app.WithReconnect(func(app excel.Application) error {
+			attempts++
+			if attempts == 1 {
+				// Simulate the server dropping the connection.
+				return fmt.Errorf("call failed: %w", ole.NewError(0x80010108))
+			}
+			return app.Err()
+		})
+		if err != nil {
+			t.Fatalf("WithReconnect failed: %v", err)
+		}
+		if attempts != 2 {
+			t.Errorf("expected 2 attempts, got %d", attempts)
+		}
+
+		got, err := app.Hwnd()
+		if err != nil || got != want {
+			t.Errorf("expected reconnected Hwnd %v, got %v (%v)", want, got, err)
+		}
+		return nil
+	})
+}
+
+func TestExcel_ReconnectByPID(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		target, other := excel.NewApplication(ctx), excel.NewApplication(ctx)
+		for _, app := range []excel.Application{target, other} {
+			if err := app.Err(); err != nil {
+				t.Skip("Excel not installed:", err)
+				return nil
+			}
+			defer app.Put("DisplayAlerts", false).Call("Quit")
+			app.Workbooks().Add()
+		}
+		// Make the other instance the one registered as active.
+		other.Put("Visible", true)
+
+		pid, err := target.ProcessID()
+		if err != nil {
+			t.Fatalf("failed to get process ID: %v", err)
+		}
+		bound := excel.GetApplicationByPID(ctx, pid)
+		if err := bound.Reconnect(); err != nil {
+			t.Fatalf("Reconnect failed: %v", err)
+		}
+
+		want, _ := target.Hwnd()
+		got, err := bound.Hwnd()
+		if err != nil || got != want {
+			t.Errorf("expected to reconnect to process %d (Hwnd %v), got Hwnd %v (%v)", pid, want, got, err)
+		}
+		return nil
+	})
+}
+
+func TestExcel_GetOrAdd(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		sheets := app.Workbooks().Add().Worksheets()
+		existing, _ := sheets.Item(1).Get("Name").GetString()
+		before, _ := sheets.Len()
+
+		for _, name := range []string{existing, "Report"} {
+			ws := sheets.GetOrAdd(name)
+			if err := ws.Err(); err != nil {
+				t.Fatalf("GetOrAdd(%q) failed: %v", name, err)
+			}
+			if got, _ := ws.Get("Name").GetString(); got != name {
+				t.Errorf("expected sheet %q, got %q", name, got)
+			}
+		}
+
+		if after, _ := sheets.Len(); after != before+1 {
+			t.Errorf("expected exactly one sheet to be added, had %d now %d", before, after)
+		}
+		return nil
+	})
+}
+
+func TestExcel_ToCSV(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := app.Workbooks().Add().ActiveSheet()
+		sheet.Range("A1").SetValue("name")
+		sheet.Range("B1").SetValue("note")
+		sheet.Range("A2").SetValue("apple")
+		sheet.Range("B2").SetValue(`say "hi", please`)
+		sheet.Range("A3").SetValue(1.5)
+
+		var buf bytes.Buffer
+		if err := sheet.Range("A1:B3").ToCSV(&buf, excel.CSVOptions{}); err != nil {
+			t.Fatalf("ToCSV failed: %v", err)
+		}
+		want := "name,note\napple,\"say \"\"hi\"\", please\"\n1.5,\n"
+		if got := buf.String(); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+
+		buf.Reset()
+		if err := sheet.Range("A1:B1").ToCSV(&buf, excel.CSVOptions{Comma: ';', QuoteAll: true}); err != nil {
+			t.Fatalf("ToCSV failed: %v", err)
+		}
+		if got := buf.String(); got != "\"name\";\"note\"\n" {
+			t.Errorf("unexpected quoted output %q", got)
+		}
+		return nil
+	})
+}
+
+func TestExcel_RangeRC(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := app.Workbooks().Add().ActiveSheet()
+		data := [][]interface{}{{1, 2, 3}, {4, 5, 6}}
+		if err := sheet.RangeRC(2, 2, 3, 4).SetValues2D(data).Err(); err != nil {
+			t.Fatalf("failed to write grid: %v", err)
+		}
+
+		addr, err := sheet.RangeRC(2, 2, 3, 4).Get("Address").GetString()
+		if err != nil || addr != "$B$2:$D$3" {
+			t.Errorf("expected $B$2:$D$3, got %q (%v)", addr, err)
+		}
+		grid, err := sheet.RangeRC(2, 2, 3, 4).Values2D()
+		if err != nil {
+			t.Fatalf("failed to read grid: %v", err)
+		}
+		if grid[1][2] != float64(6) {
+			t.Errorf("expected 6 at (3, 4), got %v", grid[1][2])
+		}
+		return nil
+	})
+}
+
+func TestExcel_FullScreen(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		if visible, _ := app.Get("Visible").GetBool(); !visible {
+			t.Skip("full-screen mode needs a visible Excel window")
+		}
+		app.Workbooks().Add()
+
+		if err := app.SetFullScreen(true).Err(); err != nil {
+			t.Fatalf("SetFullScreen failed: %v", err)
+		}
+		if on, err := app.Get("DisplayFullScreen").GetBool(); err != nil || !on {
+			t.Errorf("expected DisplayFullScreen to be true, got %v (%v)", on, err)
+		}
+		if err := app.ShowRibbon(false); err != nil {
+			t.Errorf("ShowRibbon(false) failed: %v", err)
+		}
+		if err := app.ShowRibbon(true); err != nil {
+			t.Errorf("ShowRibbon(true) failed: %v", err)
+		}
+		app.SetFullScreen(false)
+		return nil
+	})
+}
+
+func TestExcel_PageSetup(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := app.Workbooks().Add().ActiveSheet()
+		setup := sheet.PageSetup().
+			SetOrientation(excel.XlLandscape).
+			SetPrintArea(sheet.Range("A1:C10")).
+			SetHeader("Report")
+		if err := setup.Err(); err != nil {
+			// PageSetup needs a printer driver, which build machines may lack.
+			t.Skip("PageSetup unavailable:", err)
+		}
+
+		orientation, err := sheet.PageSetup().Get("Orientation").GetInt()
+		if err != nil || excel.XlPageOrientation(orientation) != excel.XlLandscape {
+			t.Errorf("expected landscape orientation, got %d (%v)", orientation, err)
+		}
+		area, err := sheet.PageSetup().Get("PrintArea").GetString()
+		if err != nil || area != "$A$1:$C$10" {
+			t.Errorf("expected print area $A$1:$C$10, got %q (%v)", area, err)
+		}
+		return nil
+	})
+}
+
+func TestExcel_ExportPDF(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		wb := app.Workbooks().Add()
+		sheet := wb.ActiveSheet()
+		sheet.Range("A1").SetValue("Sugar PDF")
+
+		dir := t.TempDir()
+		for name, export := range map[string]func(string) error{
+			"workbook.pdf":  func(p string) error { return wb.ExportPDF(p) },
+			"worksheet.pdf": func(p string) error { return sheet.ExportPDF(p, excel.PDFQuality(excel.XlQualityMinimum)) },
+			// A to of 0 runs to the last page.
+			"from1.pdf": func(p string) error { return sheet.ExportPDF(p, excel.PDFPageRange(1, 0)) },
+		} {
+			path := filepath.Join(dir, name)
+			if err := export(path); err != nil {
+				t.Fatalf("%s: ExportPDF failed: %v", name, err)
+			}
+			info, err := os.Stat(path)
+			if err != nil || info.Size() == 0 {
+				t.Errorf("%s: expected a non-empty PDF, got %v (%v)", name, info, err)
+			}
+		}
+		return nil
+	})
+}
+
+func TestExcel_ListObjects(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := app.Workbooks().Add().ActiveSheet()
+		sheet.Range("A1:B3").SetValues2D([][]interface{}{
+			{"name", "qty"},
+			{"apple", 3},
+			{"pear", 5},
+		})
+
+		table := sheet.ListObjects().Add(sheet.Range("A1:B3"), true).SetName("Fruit")
+		if err := table.Err(); err != nil {
+			t.Fatalf("failed to add table: %v", err)
+		}
+		if name, err := table.Name(); err != nil || name != "Fruit" {
+			t.Errorf("expected name Fruit, got %q (%v)", name, err)
+		}
+		if addr, err := table.DataBodyRange().Get("Address").GetString(); err != nil || addr != "$A$2:$B$3" {
+			t.Errorf("expected data body $A$2:$B$3, got %q (%v)", addr, err)
+		}
+		if addr, err := table.HeaderRowRange().Get("Address").GetString(); err != nil || addr != "$A$1:$B$1" {
+			t.Errorf("expected header row $A$1:$B$1, got %q (%v)", addr, err)
+		}
+
+		table.ListRows().Add().Range().SetValues2D([][]interface{}{{"plum", 1}})
+		if addr, err := sheet.ListObjects().Item("Fruit").DataBodyRange().Get("Address").GetString(); err != nil || addr != "$A$2:$B$4" {
+			t.Errorf("expected the table to grow to $A$2:$B$4, got %q (%v)", addr, err)
+		}
+
+		if n, err := sheet.ListObjects().Count(); err != nil || n != 1 {
+			t.Errorf("expected 1 table, got %d (%v)", n, err)
+		}
+		rows := table.ListRows()
+		if n, err := rows.Count(); err != nil || n != 3 {
+			t.Errorf("expected 3 rows, got %d (%v)", n, err)
+		}
+		var names []string
+		err := rows.Each(func(row excel.ListRow) error {
+			name, err := row.Range().Get("Cells", 1, 1).Get("Value").GetString()
+			names = append(names, name)
+			return err
+		})
+		if err != nil || !reflect.DeepEqual(names, []string{"apple", "pear", "plum"}) {
+			t.Errorf("expected rows apple, pear, plum, got %v (%v)", names, err)
+		}
+		return nil
+	})
+}
+
+func TestExcel_Hyperlink(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := app.Workbooks().Add().ActiveSheet()
+		if err := sheet.Range("A1").AddHyperlink("https://example.com/", "Example").Err(); err != nil {
+			t.Fatalf("AddHyperlink failed: %v", err)
+		}
+
+		address, text, err := sheet.Range("A1").Hyperlink()
+		if err != nil {
+			t.Fatalf("Hyperlink failed: %v", err)
+		}
+		if address != "https://example.com/" || text != "Example" {
+			t.Errorf("unexpected hyperlink %q / %q", address, text)
+		}
+
+		if _, _, err := sheet.Range("B1").Hyperlink(); !errors.Is(err, sugar.ErrNotFound) {
+			t.Errorf("expected ErrNotFound for a cell without link, got %v", err)
+		}
+		if _, _, err := sheet.Range("A1:B2").Hyperlink(); err == nil {
+			t.Error("expected error for a multi-cell range")
+		}
+		return nil
+	})
+}
+
+func TestExcel_RangeValueFormula(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := app.Workbooks().Add().ActiveSheet()
+		sheet.Range("A1").SetValue(2)
+		sheet.Range("A2").SetValue(3)
+		if err := sheet.Range("A3").SetFormula("=A1*A2").Err(); err != nil {
+			t.Fatalf("SetFormula failed: %v", err)
+		}
+
+		if f, err := sheet.Range("A3").Formula(); err != nil || f != "=A1*A2" {
+			t.Errorf("expected formula =A1*A2, got %q (%v)", f, err)
+		}
+		if v, err := sheet.Range("A3").CellValue(); err != nil || v != float64(6) {
+			t.Errorf("expected value 6, got %v (%v)", v, err)
+		}
+		return nil
+	})
+}
+
+func TestExcel_RangeSetValues(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := app.Workbooks().Add().ActiveSheet()
+		data := [][]interface{}{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}
+		if err := sheet.Range("A1:C3").SetValues(data).Err(); err != nil {
+			t.Fatalf("SetValues failed: %v", err)
+		}
+		if v, err := sheet.Range("C3").CellValue(); err != nil || v != float64(9) {
+			t.Errorf("expected C3 = 9, got %v (%v)", v, err)
+		}
+
+		ragged := [][]interface{}{{1, 2}, {3}}
+		if err := sheet.Range("A1:B2").SetValues(ragged).Err(); err == nil {
+			t.Error("expected error for ragged grid")
+		}
+		return nil
+	})
+}
+
+func TestExcel_SaveAs(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		wb := app.Workbooks().Add()
+		defer wb.Close()
+		wb.ActiveSheet().Range("A1").SetValue("saved")
+
+		dir := t.TempDir()
+		cases := []struct {
+			name string
+			opts []excel.SaveOption
+		}{
+			{"book.xlsx", []excel.SaveOption{excel.SaveFormat(excel.XlOpenXMLWorkbook)}},
+			{"book.xlsb", []excel.SaveOption{excel.SaveFormat(excel.XlExcel12), excel.SavePassword("secret")}},
+			{"book.csv", []excel.SaveOption{excel.SaveFormat(excel.XlCSV)}},
+			{"book.pdf", []excel.SaveOption{excel.SaveFormat(excel.XlPDF)}},
+		}
+		for _, tc := range cases {
+			path := filepath.Join(dir, tc.name)
+			if err := wb.SaveAs(path, tc.opts...); err != nil {
+				t.Errorf("SaveAs(%s) failed: %v", tc.name, err)
+				continue
+			}
+			if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+				t.Errorf("expected %s to be written: %v", tc.name, err)
+			}
+		}
+
+		if err := wb.SaveAs(filepath.Join(dir, "x.pdf"), excel.SaveFormat(excel.XlPDF), excel.SavePassword("p")); err == nil {
+			t.Error("expected error for password-protected PDF")
+		}
+		return nil
+	})
+}
+
+func TestExcel_WorkbooksOpen(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		path := filepath.Join(t.TempDir(), "open.xlsx")
+		wb := app.Workbooks().Add()
+		wb.ActiveSheet().Range("A1").SetValue("hello")
+		if err := wb.SaveAs(path, excel.SavePassword("secret")); err != nil {
+			t.Fatalf("SaveAs failed: %v", err)
+		}
+		wb.Close()
+
+		opened := app.Workbooks().Open(path, excel.OpenReadOnly(), excel.OpenPassword("secret"), excel.OpenUpdateLinks(false))
+		if err := opened.Err(); err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer opened.Close()
+
+		if ro, err := opened.Get("ReadOnly").GetBool(); err != nil || !ro {
+			t.Errorf("expected read-only workbook, got %v (%v)", ro, err)
+		}
+		if v, err := opened.ActiveSheet().Range("A1").CellValue(); err != nil || v != "hello" {
+			t.Errorf("expected A1 = hello, got %v (%v)", v, err)
+		}
+		return nil
+	})
+}
+
+func TestExcel_WorksheetName(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		wb := app.Workbooks().Add()
+		sheet := wb.ActiveSheet()
+		if err := sheet.SetName("Report").Err(); err != nil {
+			t.Fatalf("SetName failed: %v", err)
+		}
+		if name, err := sheet.Name(); err != nil || name != "Report" {
+			t.Errorf("expected name Report, got %q (%v)", name, err)
+		}
+
+		other := wb.Worksheets().GetOrAdd("Other")
+		if err := other.SetName("Report").Err(); err == nil {
+			t.Error("expected error for duplicate sheet name")
+		}
+		if err := other.SetName("bad/name").Err(); err == nil {
+			t.Error("expected error for invalid sheet name")
+		}
+		return nil
+	})
+}
+
+func TestExcel_ApplicationToggles(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		// Calculation can only be set while a workbook is open.
+		app.Workbooks().Add()
+
+		err := app.SetScreenUpdating(false).
+			SetCalculation(excel.CalculationManual).
+			SetEnableEvents(false).Err()
+		if err != nil {
+			t.Fatalf("toggles failed: %v", err)
+		}
+		defer app.SetScreenUpdating(true).SetCalculation(excel.CalculationAutomatic).SetEnableEvents(true)
+
+		if on, err := app.Get("ScreenUpdating").GetBool(); err != nil || on {
+			t.Errorf("expected ScreenUpdating false, got %v (%v)", on, err)
+		}
+		if mode, err := app.Get("Calculation").GetInt(); err != nil || excel.CalculationMode(mode) != excel.CalculationManual {
+			t.Errorf("expected manual calculation, got %v (%v)", mode, err)
+		}
+		if on, err := app.Get("EnableEvents").GetBool(); err != nil || on {
+			t.Errorf("expected EnableEvents false, got %v (%v)", on, err)
+		}
+		return nil
+	})
+}
+
+func TestExcel_RangeNavigation(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := app.Workbooks().Add().ActiveSheet()
+		sheet.Range("A1:A5").SetValue(1)
+
+		check := func(name string, r excel.Range, want string) {
+			t.Helper()
+			addr, err := r.Get("Address", false, false).GetString()
+			if err != nil || addr != want {
+				t.Errorf("%s: expected %s, got %q (%v)", name, want, addr, err)
+			}
+		}
+		check("Offset", sheet.Range("A1").Offset(2, 1), "B3")
+		check("Resize", sheet.Range("B2").Resize(2, 3), "B2:D3")
+		check("End(XlDown)", sheet.Range("A1").End(excel.XlDown), "A5")
+		check("End(XlUp)", sheet.Range("A100").End(excel.XlUp), "A5")
+		check("End(XlToLeft)", sheet.Range("D1").End(excel.XlToLeft), "A1")
+		return nil
+	})
+}
+
+func TestRGB(t *testing.T) {
+	if got := excel.RGB(255, 0, 0); got != 0x0000FF {
+		t.Errorf("RGB(255, 0, 0) = %#x, want 0xff", got)
+	}
+	if got := excel.RGB(0x12, 0x34, 0x56); got != 0x563412 {
+		t.Errorf("RGB(0x12, 0x34, 0x56) = %#x, want 0x563412", got)
+	}
+}
+
+func TestExcel_RangeFormatting(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		r := app.Workbooks().Add().ActiveSheet().Range("A1:B2")
+		err := r.SetNumberFormat("0.00").
+			SetFontBold(true).
+			SetFontColor(excel.RGB(255, 0, 0)).
+			SetInteriorColor(excel.RGB(0, 0, 255)).Err()
+		if err != nil {
+			t.Fatalf("formatting failed: %v", err)
+		}
+
+		if f, err := r.Get("NumberFormat").GetString(); err != nil || f != "0.00" {
+			t.Errorf("expected NumberFormat 0.00, got %q (%v)", f, err)
+		}
+		if bold, err := r.Get("Font").Get("Bold").GetBool(); err != nil || !bold {
+			t.Errorf("expected bold font, got %v (%v)", bold, err)
+		}
+		if c, err := r.Get("Font").Get("Color").GetInt(); err != nil || c != 0xFF {
+			t.Errorf("expected red font, got %#x (%v)", c, err)
+		}
+		if c, err := r.Get("Interior").Get("Color").GetInt(); err != nil || c != 0xFF0000 {
+			t.Errorf("expected blue interior, got %#x (%v)", c, err)
+		}
+		return nil
+	})
+}
+
+func TestExcel_ForEachSheet(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		wb := app.Workbooks().Add()
+		wb.Worksheets().GetOrAdd("Second")
+		wb.Worksheets().GetOrAdd("Third")
+
+		count, err := wb.SheetCount()
+		if err != nil {
+			t.Fatalf("SheetCount failed: %v", err)
+		}
+
+		var names []string
+		err = wb.ForEachSheet(func(sheet excel.Worksheet) error {
+			name, err := sheet.Name()
+			names = append(names, name)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("ForEachSheet failed: %v", err)
+		}
+		if len(names) != count {
+			t.Errorf("expected %d sheets, visited %v", count, names)
+		}
+
+		visited := 0
+		err = wb.ForEachSheet(func(sheet excel.Worksheet) error {
+			visited++
+			return sugar.ErrForEachBreak
+		})
+		if err != nil || visited != 1 {
+			t.Errorf("expected break after one sheet without error, visited %d (%v)", visited, err)
+		}
+		return nil
+	})
+}
+
+func TestExcel_WorksheetLifecycle(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		wb := app.Workbooks().Add()
+		first := wb.ActiveSheet()
+		first.SetName("First")
+		last := wb.Worksheets().GetOrAdd("Last")
+		start, _ := wb.SheetCount()
+
+		if err := first.Copy(nil, last); err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if n, _ := wb.SheetCount(); n != start+1 {
+			t.Errorf("expected %d sheets after Copy, got %d", start+1, n)
+		}
+
+		if err := last.Move(first, nil); err != nil {
+			t.Fatalf("Move failed: %v", err)
+		}
+		if name, _ := wb.Worksheets().Item(1).Name(); name != "Last" {
+			t.Errorf("expected Last to be the first sheet after Move, got %q", name)
+		}
+
+		if err := wb.Worksheets().Item("Last").Delete(); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		if n, _ := wb.SheetCount(); n != start {
+			t.Errorf("expected %d sheets after Delete, got %d", start, n)
+		}
+		return nil
+	})
+}
+
+func TestExcel_ApplicationRun(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		wb := app.Workbooks().Add()
+		if _, err := app.Run("NoSuchMacro"); err == nil || !strings.Contains(err.Error(), "NoSuchMacro") {
+			t.Errorf("expected an error naming the missing macro, got %v", err)
+		}
+		if _, err := app.Run("Twice", make([]interface{}, 31)...); err == nil {
+			t.Error("expected an error for more than 30 arguments")
+		}
+
+		// Adding code needs "Trust access to the VBA project object model".
+		module := wb.Get("VBProject").Get("VBComponents").Call("Add", 1)
+		if err := module.Err(); err != nil {
+			t.Skip("VBA project access not trusted:", err)
+			return nil
+		}
+		module.Get("CodeModule").Call("AddFromString", "Function Twice(x)\r\nTwice = x * 2\r\nEnd Function")
+
+		res, err := app.Run("Twice", 21)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if fmt.Sprint(res) != "42" {
+			t.Errorf("expected 42, got %v (%T)", res, res)
+		}
+		return nil
+	})
+}
+
+func TestExcel_WorksheetFunction(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		fn := app.WorksheetFunction()
+		if err := fn.Err(); err != nil {
+			t.Fatalf("WorksheetFunction failed: %v", err)
+		}
+		sum, err := fn.Call("Sum", 1, 2, 3)
+		if err != nil {
+			t.Fatalf("Sum failed: %v", err)
+		}
+		if fmt.Sprint(sum) != "6" {
+			t.Errorf("expected 6, got %v (%T)", sum, sum)
+		}
+
+		ws := app.Workbooks().Add().ActiveSheet()
+		table := ws.Range("A1:B2")
+		table.SetValue([][]interface{}{{"a", 1}, {"b", 2}})
+		got, err := fn.Call("VLookup", "b", table, 2, false)
+		if err != nil {
+			t.Fatalf("VLookup failed: %v", err)
+		}
+		if fmt.Sprint(got) != "2" {
+			t.Errorf("expected 2, got %v (%T)", got, got)
+		}
+		if _, err := fn.Call("VLookup", "z", table, 2, false); err == nil {
+			t.Error("expected an error for a missing lookup value")
+		}
+		return nil
+	})
+}
+
+func ExampleRange_FindCell() {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			return err
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := app.Workbooks().Add().ActiveSheet()
+		data := sheet.Range("A1:B3")
+		data.SetValues([][]interface{}{{"Apple", 3}, {"Pear", 5}, {"Plum", 2}})
+
+		cell, err := data.FindCell("pear", excel.FindWholeCell())
+		if errors.Is(err, sugar.ErrNotFound) {
+			fmt.Println("no pears")
+			return nil
+		} else if err != nil {
+			return err
+		}
+		qty, err := cell.Offset(0, 1).CellValue()
+		if err != nil {
+			return err
+		}
+		fmt.Println("pears:", qty)
+		return nil
+	})
+}
+
+// This example sorts a table by its second column, leaving the header row
+// in place.
+func ExampleRange_Sort() {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			return err
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := app.Workbooks().Add().ActiveSheet()
+		sheet.Range("A1:B4").SetValues([][]interface{}{
+			{"Name", "Qty"},
+			{"Apple", 3},
+			{"Pear", 5},
+			{"Plum", 2},
+		})
+
+		rows := sheet.Range("A2:B4")
+		return rows.Sort(sheet.Range("B2"), excel.SortDescending)
+	})
+}
+
+func TestExcel_FindSort(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := app.Workbooks().Add().ActiveSheet()
+		data := sheet.Range("A1:B3")
+		data.SetValues([][]interface{}{{"Pear", 5}, {"apple", 3}, {"Plum", 2}})
+
+		cell, err := data.FindCell("Apple")
+		if err != nil {
+			t.Fatalf("FindCell failed: %v", err)
+		}
+		if addr, _ := cell.Get("Address").GetString(); addr != "$A$2" {
+			t.Errorf("expected $A$2, got %s", addr)
+		}
+		if _, err := data.FindCell("Apple", excel.FindMatchCase()); !errors.Is(err, sugar.ErrNotFound) {
+			t.Errorf("expected ErrNotFound for a case-sensitive search, got %v", err)
+		}
+		if _, err := data.FindCell("P", excel.FindWholeCell()); !errors.Is(err, sugar.ErrNotFound) {
+			t.Errorf("expected ErrNotFound for a whole-cell search, got %v", err)
+		}
+
+		if err := data.Sort(sheet.Range("B1"), excel.SortAscending); err != nil {
+			t.Fatalf("Sort failed: %v", err)
+		}
+		got, err := sheet.Range("A1:A3").Get("Value").Values2D()
+		if err != nil {
+			t.Fatalf("reading sorted values failed: %v", err)
+		}
+		if fmt.Sprint(got) != "[[Plum] [apple] [Pear]]" {
+			t.Errorf("unexpected order %v", got)
+		}
+		return nil
+	})
+}
+
+func TestExcel_MergeAutoFit(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := app.Workbooks().Add().ActiveSheet()
+		title := sheet.Range("A1:C1")
+		if err := title.Merge().SetValue("Quarterly report").Err(); err != nil {
+			t.Fatalf("Merge failed: %v", err)
+		}
+		if merged, _ := sheet.Range("B1").Get("MergeCells").Value(); merged != true {
+			t.Errorf("expected B1 to be merged, got %v", merged)
+		}
+		if err := title.UnMerge().Err(); err != nil {
+			t.Fatalf("UnMerge failed: %v", err)
+		}
+		if merged, _ := sheet.Range("B1").Get("MergeCells").Value(); merged != false {
+			t.Errorf("expected B1 to be unmerged, got %v", merged)
+		}
+
+		col := sheet.Range("D1")
+		before, _ := col.Get("ColumnWidth").GetFloat()
+		if err := col.SetValue("a rather long piece of text").AutoFit().Err(); err != nil {
+			t.Fatalf("AutoFit failed: %v", err)
+		}
+		if after, _ := col.Get("ColumnWidth").GetFloat(); after <= before {
+			t.Errorf("expected the column to widen from %v, got %v", before, after)
+		}
+		return nil
+	})
+}
+
+func TestExcel_Collection(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := excel.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer app.Put("DisplayAlerts", false).Call("Quit")
+
+		wb := app.Workbooks().Add()
+		wb.Get("Names").Call("Add", "Total", "=Sheet1!$A$1")
+		wb.Get("Names").Call("Add", "Rate", "=Sheet1!$B$1")
+
+		names := excel.NewCollection(wb.Get("Names"), func(item sugar.Chain) sugar.Chain { return item })
+		if n, err := names.Count(); err != nil || n != 2 {
+			t.Fatalf("expected 2 names, got %d (%v)", n, err)
+		}
+		if got, _ := names.Item("Rate").Get("RefersTo").GetString(); got != "=Sheet1!$B$1" {
+			t.Errorf("unexpected RefersTo %q", got)
+		}
+
+		var seen []string
+		err := names.Each(func(item sugar.Chain) error {
+			name, err := item.Get("Name").GetString()
+			seen = append(seen, name)
+			if err == nil {
+				err = sugar.ErrForEachBreak
+			}
+			return err
+		})
+		if err != nil || len(seen) != 1 {
+			t.Errorf("expected Each to stop after one item, got %v (%v)", seen, err)
+		}
+		return nil
+	})
+}
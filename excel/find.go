@@ -0,0 +1,96 @@
+//go:build windows
+
+package excel
+
+import (
+	"fmt"
+
+	"github.com/xll-gen/sugar"
+)
+
+// LookIn selects what Range.FindCell searches (XlFindLookIn).
+type LookIn int
+
+const (
+	LookInValues   LookIn = -4163
+	LookInFormulas LookIn = -4123
+	LookInComments LookIn = -4144
+)
+
+// SortOrder is the order Range.Sort sorts in (XlSortOrder).
+type SortOrder int
+
+const (
+	SortAscending  SortOrder = 1
+	SortDescending SortOrder = 2
+)
+
+// XlLookAt, XlSearchOrder, XlSearchDirection and XlYesNoGuess values passed
+// to FindCell and Sort.
+const (
+	xlWhole    = 1
+	xlPart     = 2
+	xlByRows   = 1
+	xlNext     = 1
+	xlHeaderNo = 2
+)
+
+// FindOption configures Range.FindCell.
+type FindOption func(*findOptions)
+
+type findOptions struct {
+	lookIn    LookIn
+	wholeCell bool
+	matchCase bool
+}
+
+// FindLookIn sets what is searched. Defaults to LookInValues.
+func FindLookIn(lookIn LookIn) FindOption {
+	return func(o *findOptions) {
+		o.lookIn = lookIn
+	}
+}
+
+// FindWholeCell matches only cells whose entire contents equal the search
+// value, rather than cells containing it.
+func FindWholeCell() FindOption {
+	return func(o *findOptions) {
+		o.wholeCell = true
+	}
+}
+
+// FindMatchCase makes the search case-sensitive.
+func FindMatchCase() FindOption {
+	return func(o *findOptions) {
+		o.matchCase = true
+	}
+}
+
+func (r *excelRange) FindCell(what interface{}, opts ...FindOption) (Range, error) {
+	o := findOptions{lookIn: LookInValues}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	lookAt := xlPart
+	if o.wholeCell {
+		lookAt = xlWhole
+	}
+
+	// What, After, LookIn, LookAt, SearchOrder, SearchDirection, MatchCase.
+	// Excel remembers the last settings used, so all of them are passed.
+	res := r.Call("Find", what, sugar.Missing, int(o.lookIn), lookAt, xlByRows, xlNext, o.matchCase)
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	// Find returns Nothing when no cell matches.
+	if !res.IsDispatch() || res.IsNothing() {
+		return nil, fmt.Errorf("excel: finding %v: %w", what, sugar.ErrNotFound)
+	}
+	return &excelRange{res}, nil
+}
+
+func (r *excelRange) Sort(key Range, order SortOrder) error {
+	// Key1, Order1, Key2, Type, Order2, Key3, Order3, Header.
+	return r.Call("Sort", key, int(order), sugar.Missing, sugar.Missing, sugar.Missing,
+		sugar.Missing, sugar.Missing, xlHeaderNo).Err()
+}
@@ -0,0 +1,122 @@
+//go:build windows
+
+package excel
+
+import "github.com/xll-gen/sugar"
+
+// Constants of ListObjects.Add.
+const (
+	xlSrcRange = 1
+	xlYes      = 1
+	xlNo       = 2
+)
+
+// ListObjects represents the tables (ListObjects) of a worksheet.
+type ListObjects interface {
+	sugar.Chain
+	// Add creates a table over rng. With hasHeaders, the first row of rng
+	// becomes the header row.
+	Add(rng Range, hasHeaders bool) ListObject
+	// Item returns a specific table by index or name.
+	Item(index interface{}) ListObject
+	// Count returns the number of tables.
+	Count() (int, error)
+	// Each calls fn for each table in order, as Collection.Each does.
+	Each(fn func(table ListObject) error) error
+}
+
+type listObjects struct {
+	*Collection[ListObject]
+}
+
+func newListObjects(ch sugar.Chain) *listObjects {
+	return &listObjects{NewCollection(ch, func(item sugar.Chain) ListObject { return &listObject{item} })}
+}
+
+func (l *listObjects) Add(rng Range, hasHeaders bool) ListObject {
+	headers := xlNo
+	if hasHeaders {
+		headers = xlYes
+	}
+	return &listObject{l.Call("Add", xlSrcRange, rng, sugar.Missing, headers)}
+}
+
+// ListObject represents an Excel table.
+type ListObject interface {
+	sugar.Chain
+	// Name returns the name of the table.
+	Name() (string, error)
+	// SetName renames the table.
+	SetName(name string) ListObject
+	// DataBodyRange returns the range of the table without its header and
+	// total rows.
+	DataBodyRange() Range
+	// HeaderRowRange returns the header row of the table.
+	HeaderRowRange() Range
+	// ListRows returns the rows of the table.
+	ListRows() ListRows
+}
+
+type listObject struct {
+	sugar.Chain
+}
+
+func (l *listObject) Name() (string, error) {
+	return l.Get("Name").GetString()
+}
+
+func (l *listObject) SetName(name string) ListObject {
+	return &listObject{l.Put("Name", name)}
+}
+
+func (l *listObject) DataBodyRange() Range {
+	return &excelRange{l.Get("DataBodyRange")}
+}
+
+func (l *listObject) HeaderRowRange() Range {
+	return &excelRange{l.Get("HeaderRowRange")}
+}
+
+func (l *listObject) ListRows() ListRows {
+	return newListRows(l.Get("ListRows"))
+}
+
+// ListRows represents the rows of a table.
+type ListRows interface {
+	sugar.Chain
+	// Add appends a row to the table, expanding it.
+	Add() ListRow
+	// Item returns a specific row by its 1-based index.
+	Item(index interface{}) ListRow
+	// Count returns the number of rows.
+	Count() (int, error)
+	// Each calls fn for each row in order, as Collection.Each does.
+	Each(fn func(row ListRow) error) error
+}
+
+type listRows struct {
+	*Collection[ListRow]
+}
+
+func newListRows(ch sugar.Chain) *listRows {
+	return &listRows{NewCollection(ch, func(item sugar.Chain) ListRow { return &listRow{item} })}
+}
+
+func (l *listRows) Add() ListRow {
+	return &listRow{l.Call("Add")}
+}
+
+// ListRow represents a row of a table.
+type ListRow interface {
+	sugar.Chain
+	// Range returns the cells of the row.
+	Range() Range
+}
+
+type listRow struct {
+	sugar.Chain
+}
+
+func (l *listRow) Range() Range {
+	return &excelRange{l.Get("Range")}
+}
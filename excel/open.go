@@ -0,0 +1,78 @@
+//go:build windows
+
+package excel
+
+import (
+	"github.com/xll-gen/sugar"
+)
+
+// OpenOption configures Workbooks.Open.
+type OpenOption func(*openOptions)
+
+type openOptions struct {
+	readOnly                  bool
+	password                  string
+	updateLinks               *bool
+	ignoreReadOnlyRecommended bool
+}
+
+// OpenReadOnly opens the workbook in read-only mode.
+func OpenReadOnly() OpenOption {
+	return func(o *openOptions) {
+		o.readOnly = true
+	}
+}
+
+// OpenPassword supplies the password required to open a protected workbook.
+func OpenPassword(password string) OpenOption {
+	return func(o *openOptions) {
+		o.password = password
+	}
+}
+
+// OpenUpdateLinks sets whether external references are updated on open.
+// By default Excel follows the user's setting, which may prompt.
+func OpenUpdateLinks(update bool) OpenOption {
+	return func(o *openOptions) {
+		o.updateLinks = &update
+	}
+}
+
+// OpenIgnoreReadOnlyRecommended suppresses the read-only recommended prompt.
+func OpenIgnoreReadOnlyRecommended() OpenOption {
+	return func(o *openOptions) {
+		o.ignoreReadOnlyRecommended = true
+	}
+}
+
+// openWorkbook calls Open on a Workbooks collection.
+func openWorkbook(ch sugar.Chain, path string, opts []OpenOption) sugar.Chain {
+	var o openOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// FileName, UpdateLinks, ReadOnly, Format, Password, WriteResPassword,
+	// IgnoreReadOnlyRecommended. Unset arguments are passed as missing and
+	// trailing ones are dropped.
+	params := []interface{}{path, sugar.Missing, sugar.Missing, sugar.Missing, sugar.Missing, sugar.Missing, sugar.Missing}
+	last := 0
+	if o.updateLinks != nil {
+		if *o.updateLinks {
+			params[1] = 3
+		} else {
+			params[1] = 0
+		}
+		last = 1
+	}
+	if o.readOnly {
+		params[2], last = true, 2
+	}
+	if o.password != "" {
+		params[4], last = o.password, 4
+	}
+	if o.ignoreReadOnlyRecommended {
+		params[6], last = true, 6
+	}
+	return ch.Call("Open", params[:last+1]...)
+}
@@ -0,0 +1,75 @@
+//go:build windows
+
+package excel
+
+import "github.com/xll-gen/sugar"
+
+// XlPageOrientation is the page orientation used when printing.
+type XlPageOrientation int
+
+const (
+	XlPortrait  XlPageOrientation = 1
+	XlLandscape XlPageOrientation = 2
+)
+
+// PageSetup represents the print settings of a worksheet.
+type PageSetup interface {
+	sugar.Chain
+	// SetOrientation sets portrait or landscape printing.
+	SetOrientation(orientation XlPageOrientation) PageSetup
+	// SetFitToPages scales the printout to fit the given number of pages wide
+	// and tall. Pass 0 to leave a dimension unconstrained.
+	SetFitToPages(wide, tall int) PageSetup
+	// SetPrintArea restricts printing to the given range.
+	SetPrintArea(rng Range) PageSetup
+	// SetHeader sets the center header text.
+	SetHeader(text string) PageSetup
+	// SetFooter sets the center footer text.
+	SetFooter(text string) PageSetup
+	// SetMargins sets the page margins, in points.
+	SetMargins(left, right, top, bottom float64) PageSetup
+}
+
+type pageSetup struct {
+	sugar.Chain
+}
+
+func (p *pageSetup) SetOrientation(orientation XlPageOrientation) PageSetup {
+	return &pageSetup{p.Put("Orientation", int(orientation))}
+}
+
+func (p *pageSetup) SetFitToPages(wide, tall int) PageSetup {
+	// FitToPages only applies once Zoom is off; False leaves a side unconstrained.
+	var w, t interface{} = wide, tall
+	if wide == 0 {
+		w = false
+	}
+	if tall == 0 {
+		t = false
+	}
+	return &pageSetup{p.Put("Zoom", false).Put("FitToPagesWide", w).Put("FitToPagesTall", t)}
+}
+
+func (p *pageSetup) SetPrintArea(rng Range) PageSetup {
+	addr := rng.Get("Address")
+	s, err := addr.GetString()
+	if err != nil {
+		return &pageSetup{addr}
+	}
+	return &pageSetup{p.Put("PrintArea", s)}
+}
+
+func (p *pageSetup) SetHeader(text string) PageSetup {
+	return &pageSetup{p.Put("CenterHeader", text)}
+}
+
+func (p *pageSetup) SetFooter(text string) PageSetup {
+	return &pageSetup{p.Put("CenterFooter", text)}
+}
+
+func (p *pageSetup) SetMargins(left, right, top, bottom float64) PageSetup {
+	return &pageSetup{p.Put("LeftMargin", left).
+		Put("RightMargin", right).
+		Put("TopMargin", top).
+		Put("BottomMargin", bottom)}
+}
@@ -0,0 +1,87 @@
+//go:build windows
+
+package excel
+
+import (
+	"fmt"
+
+	"github.com/xll-gen/sugar"
+)
+
+// XlFixedFormatType is the file format of ExportAsFixedFormat.
+type XlFixedFormatType int
+
+const (
+	XlTypePDF XlFixedFormatType = 0
+	XlTypeXPS XlFixedFormatType = 1
+)
+
+// XlFixedFormatQuality is the output quality of ExportAsFixedFormat.
+type XlFixedFormatQuality int
+
+const (
+	XlQualityStandard XlFixedFormatQuality = 0
+	XlQualityMinimum  XlFixedFormatQuality = 1
+)
+
+// PDFOption configures ExportPDF.
+type PDFOption func(*pdfOptions)
+
+type pdfOptions struct {
+	quality          XlFixedFormatQuality
+	openAfterPublish bool
+	from, to         int
+}
+
+// PDFQuality sets the output quality. Defaults to XlQualityStandard.
+func PDFQuality(quality XlFixedFormatQuality) PDFOption {
+	return func(o *pdfOptions) {
+		o.quality = quality
+	}
+}
+
+// PDFOpenAfterPublish opens the file in the default viewer once it is written.
+func PDFOpenAfterPublish() PDFOption {
+	return func(o *pdfOptions) {
+		o.openAfterPublish = true
+	}
+}
+
+// PDFPageRange exports only the 1-based pages from through to. A from of 0
+// starts at the first page and a to of 0 ends at the last.
+func PDFPageRange(from, to int) PDFOption {
+	return func(o *pdfOptions) {
+		o.from, o.to = from, to
+	}
+}
+
+// exportPDF calls ExportAsFixedFormat on a workbook or worksheet.
+func exportPDF(ch sugar.Chain, path string, opts []PDFOption) error {
+	var o pdfOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// Type, Filename, Quality, IncludeDocProperties, IgnorePrintAreas,
+	// From, To, OpenAfterPublish.
+	params := []interface{}{int(XlTypePDF), path, int(o.quality), true, false}
+	if o.from > 0 || o.to > 0 || o.openAfterPublish {
+		params = append(params, pageParam(o.from), pageParam(o.to), o.openAfterPublish)
+	}
+
+	err := ch.Call("ExportAsFixedFormat", params...).Err()
+	if code, ok := sugar.ExceptionCode(err); ok && code == 0x800A03EC {
+		// Excel 2007 reports a missing "Save as PDF" add-in with its generic error.
+		return fmt.Errorf("excel: PDF export failed, the Save as PDF add-in may be missing: %w", err)
+	}
+	return err
+}
+
+// pageParam passes an unset page bound of 0 as missing, which Excel reads as
+// the first or last page, rather than as the invalid page 0.
+func pageParam(page int) interface{} {
+	if page <= 0 {
+		return sugar.Missing
+	}
+	return page
+}
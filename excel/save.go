@@ -0,0 +1,79 @@
+//go:build windows
+
+package excel
+
+import (
+	"errors"
+
+	"github.com/xll-gen/sugar"
+)
+
+// XlFileFormat is the file format passed to Workbook.SaveAs.
+type XlFileFormat int
+
+const (
+	XlCSV                         XlFileFormat = 6
+	XlExcel12                     XlFileFormat = 50 // .xlsb
+	XlOpenXMLWorkbook             XlFileFormat = 51 // .xlsx
+	XlOpenXMLWorkbookMacroEnabled XlFileFormat = 52 // .xlsm
+	XlCSVUTF8                     XlFileFormat = 62
+
+	// XlPDF is not an Excel file format. SaveAs exports the workbook with
+	// ExportAsFixedFormat instead, leaving the workbook's own path unchanged.
+	XlPDF XlFileFormat = -1
+)
+
+// SaveOption configures SaveAs.
+type SaveOption func(*saveOptions)
+
+type saveOptions struct {
+	format   XlFileFormat
+	password string
+}
+
+// SaveFormat sets the file format. By default Excel picks the workbook's
+// current format.
+func SaveFormat(format XlFileFormat) SaveOption {
+	return func(o *saveOptions) {
+		o.format = format
+	}
+}
+
+// SavePassword protects the saved file with a password to open.
+func SavePassword(password string) SaveOption {
+	return func(o *saveOptions) {
+		o.password = password
+	}
+}
+
+// saveAs calls SaveAs on a workbook.
+func saveAs(ch sugar.Chain, path string, opts []SaveOption) error {
+	var o saveOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.format == XlPDF {
+		if o.password != "" {
+			return errors.New("excel: PDF export does not support a password")
+		}
+		return exportPDF(ch, path, nil)
+	}
+
+	// Filename, FileFormat, Password.
+	params := []interface{}{path}
+	switch {
+	case o.password != "":
+		params = append(params, formatParam(o.format), o.password)
+	case o.format != 0:
+		params = append(params, int(o.format))
+	}
+	return ch.Call("SaveAs", params...).Err()
+}
+
+func formatParam(format XlFileFormat) interface{} {
+	if format == 0 {
+		return sugar.Missing
+	}
+	return int(format)
+}
@@ -4,21 +4,57 @@ package expression
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 
 	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/file"
 	"github.com/expr-lang/expr/parser"
+	"github.com/expr-lang/expr/parser/lexer"
 	"github.com/go-ole/go-ole"
 	"github.com/xll-gen/sugar"
 )
 
+// Func is a Go function callable from expressions. Chain arguments are
+// passed as their Go values.
+type Func func(args ...interface{}) (interface{}, error)
+
 // Program represents a compiled expression.
 type Program struct {
-	node ast.Node
+	node  ast.Node
+	funcs map[string]Func
+	// target is the left-hand side of an assignment such as
+	// ActiveCell.Value = 'Hello', in which case node is the right-hand side.
+	target ast.Node
+}
+
+// WithFunc returns a copy of the Program in which name(args...) calls fn.
+// Registered functions take precedence over functions of a map environment and
+// over members of a COM environment with the same name; members of an object
+// (obj.name()) are always COM calls.
+func (p *Program) WithFunc(name string, fn Func) *Program {
+	funcs := make(map[string]Func, len(p.funcs)+1)
+	for k, f := range p.funcs {
+		funcs[k] = f
+	}
+	funcs[name] = fn
+	return &Program{node: p.node, funcs: funcs, target: p.target}
 }
 
-// Compile parses an expression.
+// Compile parses an expression. An expression of the form target = value,
+// where target is a property such as ActiveCell.Value, is an assignment:
+// running it sets the property and returns the assigned value.
 func Compile(expression string) (*Program, error) {
+	if target, value, ok := splitAssignment(expression); ok {
+		targetTree, err := parser.Parse(target)
+		if err == nil && isAssignable(targetTree.Node) {
+			valueTree, err := parser.Parse(value)
+			if err != nil {
+				return nil, err
+			}
+			return &Program{node: valueTree.Node, target: targetTree.Node}, nil
+		}
+	}
 	tree, err := parser.Parse(expression)
 	if err != nil {
 		return nil, err
@@ -26,27 +62,73 @@ func Compile(expression string) (*Program, error) {
 	return &Program{node: tree.Node}, nil
 }
 
+// splitAssignment splits expression at an = outside of brackets. Expressions
+// declaring variables with let are left alone.
+func splitAssignment(expression string) (string, string, bool) {
+	tokens, err := lexer.Lex(file.NewSource(expression))
+	if err != nil {
+		return "", "", false
+	}
+	depth := 0
+	for _, tok := range tokens {
+		switch {
+		case tok.Is(lexer.Operator, "let"):
+			return "", "", false
+		case tok.Is(lexer.Bracket, "(", "[", "{"):
+			depth++
+		case tok.Is(lexer.Bracket, ")", "]", "}"):
+			depth--
+		case tok.Is(lexer.Operator, "=") && depth == 0:
+			// Token locations count runes.
+			runes := []rune(expression)
+			return string(runes[:tok.From]), string(runes[tok.To:]), true
+		}
+	}
+	return "", "", false
+}
+
+func isAssignable(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.IdentifierNode:
+		return true
+	case *ast.MemberNode:
+		return !isIndex(n)
+	}
+	return false
+}
+
 // Run executes a compiled Program against an environment.
 func (p *Program) Run(env interface{}) (interface{}, error) {
-	var chain sugar.Chain
-	var envMap map[string]interface{}
-
-	switch v := env.(type) {
-	case sugar.Chain:
-		chain = v
-	case *ole.IDispatch:
-		chain = sugar.From(v)
-	case map[string]interface{}:
-		envMap = v
+	visitor := newVisitor(env, p.funcs)
+	if p.target == nil {
+		return visitor.eval(p.node)
 	}
 
-	visitor := &comVisitor{initialChain: chain, envMap: envMap}
-	return visitor.eval(p.node)
+	value, err := visitor.eval(p.node)
+	if err != nil {
+		return nil, err
+	}
+	if valueChain, ok := value.(sugar.Chain); ok {
+		if err := valueChain.Err(); err != nil {
+			return nil, err
+		}
+		// Objects are assigned as they are, other results by value.
+		if !valueChain.IsDispatch() {
+			if value, err = valueChain.Value(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := visitor.assign(p.target, value); err != nil {
+		return nil, err
+	}
+	return value, nil
 }
 
-// Eval parses and executes an expression.
+// Eval parses and executes an expression. Compiled expressions are cached
+// (see SetCacheSize).
 func Eval(expression string, env interface{}) (interface{}, error) {
-	p, err := Compile(expression)
+	p, err := compile(expression)
 	if err != nil {
 		return nil, err
 	}
@@ -87,53 +169,70 @@ func Store(obj interface{}, expression string) (*ole.IDispatch, error) {
 	return nil, fmt.Errorf("expression did not evaluate to a COM object")
 }
 
-// Put sets a property using an expression.
+// Put sets a property using an expression. Assignments can also be written
+// in the expression itself and run with Eval, as in
+// Eval("ActiveSheet.Range('A1').Value = 'Hello'", excel).
 func Put(obj interface{}, expression string, value interface{}) error {
-	p, err := Compile(expression)
+	p, err := compile(expression)
 	if err != nil {
 		return err
 	}
 
 	memberNode, ok := p.node.(*ast.MemberNode)
-	if !ok {
+	if !ok || p.target != nil {
 		return fmt.Errorf("invalid Put expression: must be property access")
 	}
+	return newVisitor(obj, nil).assign(memberNode, value)
+}
 
-	var chain sugar.Chain
-	var envMap map[string]interface{}
-	switch v := obj.(type) {
+type comVisitor struct {
+	initialChain sugar.Chain
+	envMap       map[string]interface{}
+	funcs        map[string]Func
+}
+
+func newVisitor(env interface{}, funcs map[string]Func) *comVisitor {
+	v := &comVisitor{funcs: funcs}
+	switch env := env.(type) {
 	case sugar.Chain:
-		chain = v
+		v.initialChain = env
 	case *ole.IDispatch:
-		chain = sugar.From(v)
+		v.initialChain = sugar.From(env)
 	case map[string]interface{}:
-		envMap = v
+		v.envMap = env
 	}
+	return v
+}
 
-	visitor := &comVisitor{initialChain: chain, envMap: envMap}
-	parentObj, err := visitor.eval(memberNode.Node)
-	if err != nil {
-		return err
-	}
+// assign sets the property named by target, a property access or a member of
+// a COM environment, to value.
+func (v *comVisitor) assign(target ast.Node, value interface{}) error {
+	switch n := target.(type) {
+	case *ast.IdentifierNode:
+		if v.initialChain == nil {
+			return fmt.Errorf("cannot assign to %s: environment is not a COM object", n.Value)
+		}
+		return v.initialChain.Put(n.Value, value).Err()
 
-	parentChain, ok := parentObj.(sugar.Chain)
-	if !ok {
-		return fmt.Errorf("parent is not COM object: %T", parentObj)
-	}
+	case *ast.MemberNode:
+		parentObj, err := v.eval(n.Node)
+		if err != nil {
+			return err
+		}
+		parentChain, ok := parentObj.(sugar.Chain)
+		if !ok {
+			return fmt.Errorf("parent is not COM object: %T", parentObj)
+		}
 
-	propName := ""
-	if id, ok := memberNode.Property.(*ast.StringNode); ok {
-		propName = id.Value
-	} else if id, ok := memberNode.Property.(*ast.IdentifierNode); ok {
-		propName = id.Value
+		propName := ""
+		if id, ok := n.Property.(*ast.StringNode); ok {
+			propName = id.Value
+		} else if id, ok := n.Property.(*ast.IdentifierNode); ok {
+			propName = id.Value
+		}
+		return parentChain.Put(propName, value).Err()
 	}
-
-	return parentChain.Put(propName, value).Err()
-}
-
-type comVisitor struct {
-	initialChain sugar.Chain
-	envMap       map[string]interface{}
+	return fmt.Errorf("cannot assign to %T", target)
 }
 
 func (v *comVisitor) eval(node ast.Node) (interface{}, error) {
@@ -154,9 +253,24 @@ func (v *comVisitor) eval(node ast.Node) (interface{}, error) {
 		if err != nil {
 			return nil, err
 		}
-		chain, ok := left.(sugar.Chain)
-		if !ok {
-			return nil, fmt.Errorf("cannot access property on type %T", left)
+		// Plain Go values, such as structs and maps from a map environment,
+		// are resolved by reflection.
+		chain, isChain := left.(sugar.Chain)
+
+		if isIndex(n) {
+			key, err := v.eval(n.Property)
+			if err != nil {
+				return nil, err
+			}
+			if keyChain, ok := key.(sugar.Chain); ok {
+				if key, err = keyChain.Value(); err != nil {
+					return nil, fmt.Errorf("index error: %w", err)
+				}
+			}
+			if !isChain {
+				return goIndex(left, key)
+			}
+			return chain.Index(key), nil
 		}
 
 		propName := ""
@@ -165,9 +279,14 @@ func (v *comVisitor) eval(node ast.Node) (interface{}, error) {
 		} else if id, ok := n.Property.(*ast.IdentifierNode); ok {
 			propName = id.Value
 		}
+		if !isChain {
+			return goMember(left, propName)
+		}
 		return chain.Get(propName), nil
 
 	case *ast.CallNode:
+		// Calls use Access so that both methods and parameterized properties
+		// such as Cells(1, 1) resolve.
 		args := make([]interface{}, len(n.Arguments))
 		for i, argNode := range n.Arguments {
 			argVal, err := v.eval(argNode)
@@ -191,10 +310,6 @@ func (v *comVisitor) eval(node ast.Node) (interface{}, error) {
 			if err != nil {
 				return nil, err
 			}
-			chain, ok := obj.(sugar.Chain)
-			if !ok {
-				return nil, fmt.Errorf("cannot call method on type %T", obj)
-			}
 
 			methodName := ""
 			if id, ok := callee.Property.(*ast.StringNode); ok {
@@ -202,18 +317,29 @@ func (v *comVisitor) eval(node ast.Node) (interface{}, error) {
 			} else if id, ok := callee.Property.(*ast.IdentifierNode); ok {
 				methodName = id.Value
 			}
-			return chain.Call(methodName, args...), nil
+			chain, ok := obj.(sugar.Chain)
+			if !ok {
+				return goCall(obj, methodName, args)
+			}
+			return chain.Access(methodName, args...), nil
 
 		case *ast.IdentifierNode:
+			if fn, ok := v.funcs[callee.Value]; ok {
+				return fn(args...)
+			}
 			if v.envMap != nil {
 				if val, ok := v.envMap[callee.Value]; ok {
-					if fn, ok := val.(func(...interface{}) (interface{}, error)); ok {
+					switch fn := val.(type) {
+					case func(...interface{}) (interface{}, error):
+						return fn(args...)
+					case Func:
 						return fn(args...)
 					}
+					return nil, fmt.Errorf("%s is not a function: %T", callee.Value, val)
 				}
 			}
 			if v.initialChain != nil {
-				return v.initialChain.Call(callee.Value, args...), nil
+				return v.initialChain.Access(callee.Value, args...), nil
 			}
 			return nil, fmt.Errorf("method not found: %s", callee.Value)
 		default:
@@ -241,6 +367,28 @@ func (v *comVisitor) eval(node ast.Node) (interface{}, error) {
 	}
 }
 
+// isIndex reports whether n is an index access such as x[1] or x["key"]
+// rather than a property access x.Name. The parser builds a MemberNode for
+// both, but only for property access does the node share its location with
+// the property.
+func isIndex(n *ast.MemberNode) bool {
+	if _, ok := n.Property.(*ast.StringNode); !ok {
+		return true
+	}
+	return n.Location() != n.Property.Location()
+}
+
+// evalBinary applies an arithmetic operator. Operands are promoted as
+// follows:
+//   - + - and * always yield a float64, and + concatenates when either
+//     operand is a string.
+//   - / and % yield an int64 when both operands have integer types, with /
+//     truncating toward zero as in Go. If either is a float, / is float
+//     division and % is the floating-point remainder (math.Mod), both as
+//     float64.
+//
+// Values read from COM are often float64 even when they look integral, such
+// as Excel cell values, so they take the float path.
 func evalBinary(op string, left, right interface{}) (interface{}, error) {
 	if lc, ok := left.(sugar.Chain); ok {
 		var err error
@@ -277,9 +425,25 @@ func evalBinary(op string, left, right interface{}) (interface{}, error) {
 			return toFloat(lv) * toFloat(rv), nil
 		}
 	case "/":
+		if isInteger(lv) && isInteger(rv) {
+			if toInt(rv) == 0 {
+				return nil, fmt.Errorf("integer division by zero")
+			}
+			return toInt(lv) / toInt(rv), nil
+		}
 		if isNumber(lv) && isNumber(rv) {
 			return toFloat(lv) / toFloat(rv), nil
 		}
+	case "%":
+		if isInteger(lv) && isInteger(rv) {
+			if toInt(rv) == 0 {
+				return nil, fmt.Errorf("integer division by zero")
+			}
+			return toInt(lv) % toInt(rv), nil
+		}
+		if isNumber(lv) && isNumber(rv) {
+			return math.Mod(toFloat(lv), toFloat(rv)), nil
+		}
 	}
 
 	return nil, fmt.Errorf("unsupported binary operation: %v %s %v", reflect.TypeOf(left), op, reflect.TypeOf(right))
@@ -295,6 +459,20 @@ func isNumber(v reflect.Value) bool {
 	return false
 }
 
+func isInteger(v reflect.Value) bool {
+	return isNumber(v) && v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64
+}
+
+func toInt(v reflect.Value) int64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	}
+	return 0
+}
+
 func toFloat(v reflect.Value) float64 {
 	switch v.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -3,9 +3,12 @@
 package expression
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/xll-gen/sugar"
+	"github.com/xll-gen/sugar/sugartest"
 )
 
 func setupExcel(t *testing.T, ctx sugar.Context) sugar.Chain {
@@ -103,3 +106,242 @@ func TestGet_Legacy(t *testing.T) {
 		return nil
 	})
 }
+
+func TestGet_ParameterizedProperty(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := excel.Get("Workbooks").Call("Add").Get("ActiveSheet")
+		sheet.Get("Cells", 1, 1).Put("Value", "Sugar")
+
+		val, err := Get(sheet, "Cells(1,1).Value")
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if val != "Sugar" {
+			t.Errorf("expected 'Sugar', got %v", val)
+		}
+		return nil
+	})
+}
+
+func TestEval_Index(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		wb := excel.Get("Workbooks").Call("Add")
+		wbName, _ := wb.Get("Name").GetString()
+		sheetName, _ := wb.Get("Worksheets").Index(1).Get("Name").GetString()
+
+		val, err := Get(excel, "Workbooks[1].Name")
+		if err != nil || val != wbName {
+			t.Errorf("expected %q, got %v (%v)", wbName, val, err)
+		}
+
+		val, err = Get(wb, "Worksheets['"+sheetName+"'].Name")
+		if err != nil || val != sheetName {
+			t.Errorf("expected %q, got %v (%v)", sheetName, val, err)
+		}
+
+		val, err = Get(wb, "Worksheets[0 + 1].Name")
+		if err != nil || val != sheetName {
+			t.Errorf("expected computed index to work, got %v (%v)", val, err)
+		}
+		return nil
+	})
+}
+
+func TestProgram_WithFunc(t *testing.T) {
+	p, err := Compile("upper(name) + '!'")
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	p = p.WithFunc("upper", func(args ...interface{}) (interface{}, error) {
+		return strings.ToUpper(fmt.Sprint(args[0])), nil
+	})
+
+	res, err := p.Run(map[string]interface{}{"name": "sugar"})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if res != "SUGAR!" {
+		t.Errorf("expected 'SUGAR!', got %v", res)
+	}
+
+	if _, err := Eval("name()", map[string]interface{}{"name": "sugar"}); err == nil {
+		t.Error("expected error calling a non-function value")
+	}
+}
+
+func TestCompileCache(t *testing.T) {
+	defer SetCacheSize(DefaultCacheSize)
+	SetCacheSize(2)
+
+	env := map[string]interface{}{"a": 1.0, "b": 2.0}
+	for _, src := range []string{"a + b", "a * b", "a - b", "a + b"} {
+		if _, err := Eval(src, env); err != nil {
+			t.Fatalf("Eval(%q) failed: %v", src, err)
+		}
+	}
+	if n := cache.order.Len(); n != 2 {
+		t.Errorf("expected the cache to hold 2 programs, got %d", n)
+	}
+	if _, ok := cache.items["a * b"]; ok {
+		t.Error("expected the least recently used program to be evicted")
+	}
+
+	SetCacheSize(0)
+	if n := cache.order.Len(); n != 0 {
+		t.Errorf("expected an empty cache after disabling it, got %d", n)
+	}
+}
+
+func BenchmarkEval(b *testing.B) {
+	env := map[string]interface{}{"price": 2.5, "qty": 4.0}
+	for _, size := range []int{0, DefaultCacheSize} {
+		b.Run(fmt.Sprintf("cache=%d", size), func(b *testing.B) {
+			SetCacheSize(size)
+			defer SetCacheSize(DefaultCacheSize)
+			for i := 0; i < b.N; i++ {
+				Eval("price * qty + 1", env)
+			}
+		})
+	}
+}
+
+type testConfig struct {
+	Book   int
+	Prefix string
+	Tags   []string
+	Limits map[string]int
+	secret string
+}
+
+func (c *testConfig) Label(name string) string { return c.Prefix + name }
+
+func (c *testConfig) Check() (bool, error) { return false, fmt.Errorf("check failed") }
+
+func TestEval_GoValues(t *testing.T) {
+	app := sugartest.NewObject().Set("Workbooks", sugartest.NewCollection(
+		sugartest.NewObject().Set("Name", "Book1"),
+		sugartest.NewObject().Set("Name", "Book2"),
+	))
+	cfg := &testConfig{Book: 2, Prefix: "in ", Tags: []string{"a", "b"}, Limits: map[string]int{"rows": 5}, secret: "x"}
+
+	sugar.Do(func(ctx sugar.Context) error {
+		env := map[string]interface{}{"app": ctx.FromDispatcher(app), "cfg": cfg}
+
+		tests := []struct {
+			expr string
+			want interface{}
+		}{
+			{"cfg.Book", 2},
+			{"cfg.Tags[1]", "b"},
+			{"cfg.Limits.rows", 5},
+			{"cfg.Limits['rows']", 5},
+			{"cfg.Label('Book1')", "in Book1"},
+			{"cfg.Label(app.Workbooks.Item(cfg.Book).Name)", "in Book2"},
+		}
+		for _, tt := range tests {
+			got, err := Get(env, tt.expr)
+			if err != nil {
+				t.Errorf("%s: %v", tt.expr, err)
+				continue
+			}
+			if got != tt.want {
+				t.Errorf("%s: expected %v, got %v", tt.expr, tt.want, got)
+			}
+		}
+
+		for _, expr := range []string{"cfg.secret", "cfg.Missing", "cfg.Check()", "cfg.Tags[5]"} {
+			if _, err := Eval(expr, env); err == nil {
+				t.Errorf("%s: expected an error", expr)
+			}
+		}
+		return nil
+	})
+}
+
+func TestEval_Assignment(t *testing.T) {
+	book := sugartest.NewObject().Set("Name", "Book1")
+	app := sugartest.NewObject().
+		Set("Caption", "Excel").
+		Set("Workbooks", sugartest.NewCollection(book))
+
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := ctx.FromDispatcher(app)
+
+		res, err := Eval("Workbooks.Item(1).Name = 'Report ' + 'Q1'", excel)
+		if err != nil {
+			t.Fatalf("assignment failed: %v", err)
+		}
+		if res != "Report Q1" {
+			t.Errorf("expected the assigned value, got %v", res)
+		}
+		if name, _ := book.Prop("Name"); name != "Report Q1" {
+			t.Errorf("expected Name to be Report Q1, got %v", name)
+		}
+
+		if _, err := Eval("Caption = Workbooks.Item(1).Name", excel); err != nil {
+			t.Fatalf("identifier assignment failed: %v", err)
+		}
+		if caption, _ := app.Prop("Caption"); caption != "Report Q1" {
+			t.Errorf("expected Caption to be Report Q1, got %v", caption)
+		}
+
+		env := map[string]interface{}{"app": excel}
+		if _, err := Eval("app.Caption = 'Env'", env); err != nil {
+			t.Fatalf("assignment through a map environment failed: %v", err)
+		}
+		if caption, _ := app.Prop("Caption"); caption != "Env" {
+			t.Errorf("expected Caption to be Env, got %v", caption)
+		}
+
+		if _, err := Eval("NoSuchProperty = 1", excel); err == nil {
+			t.Error("expected an error for an unknown property")
+		}
+		if _, err := Eval("app = 1", env); err == nil {
+			t.Error("expected an error for assigning to an environment variable")
+		}
+		if err := Put(excel, "Caption = 'x'", "y"); err == nil {
+			t.Error("expected Put to reject an assignment expression")
+		}
+		return nil
+	})
+}
+
+func TestEval_Division(t *testing.T) {
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"5 / 2", int64(2)},
+		{"1 / 3", int64(0)},
+		{"5.0 / 2", 2.5},
+		{"5 / 2.0", 2.5},
+		{"7 % 3", int64(1)},
+		{"9 % 3", int64(0)},
+		{"7.5 % 2", 1.5},
+		{"2 + 2", 4.0},
+	}
+	for _, tt := range tests {
+		got, err := Eval(tt.expr, nil)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %v (%T), got %v (%T)", tt.expr, tt.want, tt.want, got, got)
+		}
+	}
+
+	for _, expr := range []string{"1 / 0", "1 % 0"} {
+		if _, err := Eval(expr, nil); err == nil {
+			t.Errorf("%s: expected a division by zero error", expr)
+		}
+	}
+}
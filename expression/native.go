@@ -0,0 +1,136 @@
+//go:build windows
+
+package expression
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// goMember resolves name on a plain Go value: a method without arguments, an
+// exported struct field, or the key of a map with string keys.
+func goMember(obj interface{}, name string) (interface{}, error) {
+	v := reflect.ValueOf(obj)
+	if !v.IsValid() {
+		return nil, fmt.Errorf("cannot access property %s on nil", name)
+	}
+	if m := v.MethodByName(name); m.IsValid() {
+		return callGo(m, name, nil)
+	}
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, fmt.Errorf("cannot access property %s on nil %s", name, v.Type())
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if f, ok := v.Type().FieldByName(name); ok && f.IsExported() {
+			return v.FieldByIndex(f.Index).Interface(), nil
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			key := reflect.ValueOf(name).Convert(v.Type().Key())
+			if val := v.MapIndex(key); val.IsValid() {
+				return val.Interface(), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%T has no field or method %s", obj, name)
+}
+
+// goIndex indexes a Go map, slice, array or string.
+func goIndex(obj interface{}, key interface{}) (interface{}, error) {
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	switch v.Kind() {
+	case reflect.Map:
+		k, err := convertArg(key, v.Type().Key())
+		if err != nil {
+			return nil, fmt.Errorf("index error: %w", err)
+		}
+		if val := v.MapIndex(k); val.IsValid() {
+			return val.Interface(), nil
+		}
+		return nil, fmt.Errorf("key not found: %v", key)
+	case reflect.Slice, reflect.Array, reflect.String:
+		kv := reflect.ValueOf(key)
+		if !isNumber(kv) {
+			return nil, fmt.Errorf("index error: invalid index %v (%T)", key, key)
+		}
+		i := int(toFloat(kv))
+		if i < 0 || i >= v.Len() {
+			return nil, fmt.Errorf("index error: index %d out of range [0, %d)", i, v.Len())
+		}
+		return v.Index(i).Interface(), nil
+	}
+	return nil, fmt.Errorf("cannot index type %T", obj)
+}
+
+// goCall calls an exported method of a plain Go value.
+func goCall(obj interface{}, name string, args []interface{}) (interface{}, error) {
+	v := reflect.ValueOf(obj)
+	if !v.IsValid() {
+		return nil, fmt.Errorf("cannot call method %s on nil", name)
+	}
+	m := v.MethodByName(name)
+	if !m.IsValid() {
+		return nil, fmt.Errorf("%T has no method %s", obj, name)
+	}
+	return callGo(m, name, args)
+}
+
+// callGo calls fn with args converted to its parameter types. A trailing
+// error result is returned as the error.
+func callGo(fn reflect.Value, name string, args []interface{}) (interface{}, error) {
+	t := fn.Type()
+	if t.IsVariadic() && len(args) < t.NumIn()-1 || !t.IsVariadic() && len(args) != t.NumIn() {
+		return nil, fmt.Errorf("%s: expected %d arguments, got %d", name, t.NumIn(), len(args))
+	}
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var pt reflect.Type
+		if t.IsVariadic() && i >= t.NumIn()-1 {
+			pt = t.In(t.NumIn() - 1).Elem()
+		} else {
+			pt = t.In(i)
+		}
+		v, err := convertArg(arg, pt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: arg %d: %w", name, i, err)
+		}
+		in[i] = v
+	}
+
+	out := fn.Call(in)
+	if n := len(out); n > 0 && t.Out(n-1) == errorType {
+		if err, _ := out[n-1].Interface().(error); err != nil {
+			return nil, err
+		}
+		out = out[:n-1]
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out[0].Interface(), nil
+}
+
+func convertArg(arg interface{}, t reflect.Type) (reflect.Value, error) {
+	if arg == nil {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("cannot use nil as %s", t)
+	}
+	v := reflect.ValueOf(arg)
+	switch {
+	case v.Type().AssignableTo(t):
+		return v, nil
+	case isNumber(v) && isNumber(reflect.Zero(t)), v.Kind() == reflect.String && t.Kind() == reflect.String:
+		return v.Convert(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use %T as %s", arg, t)
+}
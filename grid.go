@@ -0,0 +1,193 @@
+//go:build windows
+
+package sugar
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-ole/go-ole"
+)
+
+// ValuesOption configures how two-dimensional values are read.
+type ValuesOption func(*valuesOptions)
+
+type valuesOptions struct {
+	trimEmpty bool
+	value2    bool
+}
+
+// UseValue2 reads the Value2 property instead of Value. In Excel, Value2 skips
+// the currency and date conversions, which makes it faster.
+func UseValue2() ValuesOption {
+	return func(o *valuesOptions) {
+		o.value2 = true
+	}
+}
+
+// TrimEmpty trims trailing rows and columns whose cells are all empty from a
+// grid read, so that the result matches the populated region even when the
+// source (such as Excel's UsedRange) over-reports its extent.
+func TrimEmpty() ValuesOption {
+	return func(o *valuesOptions) {
+		o.trimEmpty = true
+	}
+}
+
+// TrimTrailingEmpty returns grid without its trailing rows and columns in which
+// every cell is nil. The returned rows share storage with grid.
+func TrimTrailingEmpty(grid [][]interface{}) [][]interface{} {
+	rows := len(grid)
+	for rows > 0 && isEmptyRow(grid[rows-1]) {
+		rows--
+	}
+	grid = grid[:rows]
+
+	cols := 0
+	for _, row := range grid {
+		for c := len(row); c > cols; c-- {
+			if row[c-1] != nil {
+				cols = c
+				break
+			}
+		}
+	}
+	for i, row := range grid {
+		if len(row) > cols {
+			grid[i] = row[:cols]
+		}
+	}
+	return grid
+}
+
+func isEmptyRow(row []interface{}) bool {
+	for _, v := range row {
+		if v != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Values2D reads the Value property of a range-like object in a single call and
+// unpacks the returned two-dimensional SAFEARRAY into rows of Go values. A
+// scalar result (a single cell) becomes a 1x1 grid.
+func (c *chain) Values2D(opts ...ValuesOption) ([][]interface{}, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.disp == nil {
+		return nil, errors.New("dispatch is nil")
+	}
+
+	var o valuesOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	prop := "Value"
+	if o.value2 {
+		prop = "Value2"
+	}
+
+	result, err := c.invoke(OpGet, prop, ole.DISPATCH_PROPERTYGET, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Clear()
+
+	errorsAsErrors := c.options().errorsAsErrors
+	var grid [][]interface{}
+	switch {
+	case result.VT&ole.VT_ARRAY != 0:
+		grid, err = decodeGrid(result, errorsAsErrors)
+		if err != nil {
+			return nil, err
+		}
+	case result.VT == ole.VT_DISPATCH:
+		return nil, errors.New("result is IDispatch, use Store")
+	case result.VT == ole.VT_ERROR && errorsAsErrors:
+		return nil, &CellError{Code: uint32(result.Val)}
+	default:
+		grid = [][]interface{}{{variantValue(result)}}
+	}
+
+	if o.trimEmpty {
+		grid = TrimTrailingEmpty(grid)
+	}
+	return grid, nil
+}
+
+func decodeGrid(v *ole.VARIANT, errorsAsErrors bool) ([][]interface{}, error) {
+	arr, err := newSafeArray(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(arr.lower) != 2 {
+		return nil, errors.New("result is not a two-dimensional array")
+	}
+
+	grid := make([][]interface{}, arr.len(0))
+	for r := range grid {
+		row := make([]interface{}, arr.len(1))
+		for c := range row {
+			if row[c], err = arr.value(errorsAsErrors, r, c); err != nil {
+				return nil, err
+			}
+		}
+		grid[r] = row
+	}
+	return grid, nil
+}
+
+// SetValues2D writes a rectangular grid to the Value property of a range-like
+// object in a single COM call, as a two-dimensional SAFEARRAY.
+func (c *chain) SetValues2D(data [][]interface{}) Chain {
+	if c.err != nil || c.disp == nil {
+		return c
+	}
+
+	v, err := newGridVariant(data)
+	if err != nil {
+		return c.borrow(wrapErr(OpPut, "Value", err))
+	}
+	defer v.Clear()
+	return c.Put("Value", v)
+}
+
+// CallSlice calls a method returning a one-dimensional array and unpacks it
+// into a slice, whatever the array's lower bound.
+func (c *chain) CallSlice(method string, params ...interface{}) ([]interface{}, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.disp == nil {
+		return nil, errors.New("dispatch is nil")
+	}
+	result, err := c.invoke(OpCall, method, ole.DISPATCH_METHOD, params)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Clear()
+	if result.VT&ole.VT_ARRAY == 0 {
+		return nil, fmt.Errorf("%s did not return an array", method)
+	}
+	return decodeSlice(result, c.options().errorsAsErrors)
+}
+
+func decodeSlice(v *ole.VARIANT, errorsAsErrors bool) ([]interface{}, error) {
+	arr, err := newSafeArray(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(arr.lower) != 1 {
+		return nil, fmt.Errorf("result is a %d-dimensional array, not one-dimensional", len(arr.lower))
+	}
+
+	values := make([]interface{}, arr.len(0))
+	for i := range values {
+		if values[i], err = arr.value(errorsAsErrors, i); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
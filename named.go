@@ -0,0 +1,189 @@
+//go:build windows
+
+package sugar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/go-ole/go-ole"
+)
+
+// CallNamed calls a method passing only the named arguments.
+func (c *chain) CallNamed(method string, named map[string]interface{}) Chain {
+	if c.err != nil {
+		return &chain{err: c.err, ctx: c.ctx}
+	}
+	if c.disp == nil {
+		return &chain{err: fmt.Errorf("dispatch is nil"), ctx: c.ctx}
+	}
+	result, err := c.invokeNamed(OpCall, method, ole.DISPATCH_METHOD, named, nil, false)
+	return c.handleResult(result, err)
+}
+
+// PutNamed sets a parameterized property passing its parameters by name.
+func (c *chain) PutNamed(prop string, value interface{}, named map[string]interface{}) Chain {
+	if c.err != nil || c.disp == nil {
+		return c
+	}
+	_, err := c.invokeNamed(OpPut, prop, ole.DISPATCH_PROPERTYPUT, named, value, true)
+	if err != nil {
+		return c.borrow(err)
+	}
+	return c
+}
+
+// invokeNamed invokes member with named arguments, resolving the member and
+// argument names in one GetIDsOfNames call. For a property put, value is
+// passed as the DISPID_PROPERTYPUT argument.
+func (c *chain) invokeNamed(op, member string, flags int16, named map[string]interface{}, value interface{}, hasValue bool) (_ *ole.VARIANT, err error) {
+	if err := c.preInvoke(op, member); err != nil {
+		return nil, err
+	}
+	if done := traceStart(op, member, []interface{}{named}); done != nil {
+		defer func() { done(err) }()
+	}
+
+	// Sort for a deterministic argument order.
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ids, err := c.disp.GetIDsOfName(append([]string{member}, names...))
+	if err != nil {
+		return nil, wrapErr(op, member, fmt.Errorf("resolving argument names %v: %w", names, err))
+	}
+
+	params := make([]interface{}, 0, len(names)+1)
+	argIDs := make([]int32, 0, len(names)+1)
+	if hasValue {
+		params = append(params, value)
+		argIDs = append(argIDs, ole.DISPID_PROPERTYPUT)
+	}
+	for i, name := range names {
+		params = append(params, named[name])
+		argIDs = append(argIDs, ids[i+1])
+	}
+
+	args, release, err := prepareParams(params)
+	if err != nil {
+		return nil, wrapErr(op, member, err)
+	}
+	defer release()
+
+	vars := make([]ole.VARIANT, len(args))
+	for i, arg := range args {
+		v, owned, err := argVariant(arg)
+		if err != nil {
+			return nil, wrapErr(op, member, fmt.Errorf("argument %s: %w", argName(names, hasValue, i), err))
+		}
+		vars[i] = v
+		if owned {
+			defer ole.VariantClear(&vars[i])
+		}
+	}
+
+	var dp dispParams
+	if len(vars) > 0 {
+		dp.rgvarg = &vars[0]
+		dp.rgdispidNamedArgs = &argIDs[0]
+		dp.cArgs = uint32(len(vars))
+		dp.cNamedArgs = uint32(len(vars))
+	}
+
+	result := new(ole.VARIANT)
+	ole.VariantInit(result)
+	var info excepInfo
+	hr, _, _ := syscall.SyscallN(c.disp.VTable().Invoke,
+		uintptr(unsafe.Pointer(c.disp)),
+		uintptr(ids[0]),
+		uintptr(unsafe.Pointer(ole.IID_NULL)),
+		uintptr(ole.GetUserDefaultLCID()),
+		uintptr(flags),
+		uintptr(unsafe.Pointer(&dp)),
+		uintptr(unsafe.Pointer(result)),
+		uintptr(unsafe.Pointer(&info)),
+		0)
+	if hr != 0 {
+		return nil, wrapErr(op, member, info.error(hr))
+	}
+	return result, nil
+}
+
+func argName(names []string, hasValue bool, i int) string {
+	if hasValue {
+		if i == 0 {
+			return "value"
+		}
+		i--
+	}
+	return names[i]
+}
+
+// argVariant converts a prepared argument to a VARIANT and reports whether
+// the VARIANT owns resources that must be cleared after the call.
+func argVariant(arg interface{}) (ole.VARIANT, bool, error) {
+	switch v := arg.(type) {
+	case *ole.VARIANT:
+		return *v, false, nil
+	case *ole.IDispatch:
+		return ole.NewVariant(ole.VT_DISPATCH, int64(uintptr(unsafe.Pointer(v)))), false, nil
+	}
+	v, err := toVariant(arg)
+	return v, err == nil, err
+}
+
+// error converts the EXCEPINFO filled by a failed Invoke into an error and
+// frees its strings. For DISP_E_EXCEPTION the error carries the exception as
+// its SubError, as go-ole's Invoke does, so that ExceptionCode finds the SCODE
+// raised by the server.
+func (e *excepInfo) error(hr uintptr) error {
+	exc := &exception{scode: e.scode, wCode: e.wCode}
+	if e.bstrSource != nil {
+		exc.source = ole.BstrToString((*uint16)(unsafe.Pointer(e.bstrSource)))
+	}
+	if e.bstrDescription != nil {
+		exc.description = ole.BstrToString((*uint16)(unsafe.Pointer(e.bstrDescription)))
+	}
+	ole.SysFreeString(e.bstrSource)
+	ole.SysFreeString(e.bstrDescription)
+	ole.SysFreeString(e.bstrHelpFile)
+	if uint32(hr) == dispEException {
+		return ole.NewErrorWithSubError(hr, exc.description, exc)
+	}
+	if exc.description == "" {
+		return ole.NewError(hr)
+	}
+	return ole.NewErrorWithDescription(hr, exc.description)
+}
+
+// exception is the content of an EXCEPINFO, kept after its strings are freed.
+// Like go-ole's EXCEPINFO, it reports the server's error code with SCODE.
+type exception struct {
+	scode       uint32
+	wCode       uint16
+	source      string
+	description string
+}
+
+// SCODE returns the error code raised by the server, or 0 if it only set
+// wCode.
+func (e *exception) SCODE() uint32 {
+	return e.scode
+}
+
+func (e *exception) Error() string {
+	if e.description != "" {
+		return strings.TrimSpace(e.description)
+	}
+	code := e.scode
+	if e.wCode != 0 {
+		code = uint32(e.wCode)
+	}
+	return fmt.Sprintf("%s: %#x", e.source, code)
+}
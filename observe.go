@@ -0,0 +1,183 @@
+//go:build windows
+
+package sugar
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-ole/go-ole"
+)
+
+// Observe polls a property on the given interval and sends its value on the
+// returned channel whenever it differs from the previously observed value.
+// The value read on the first poll is used as the baseline and is not sent.
+//
+// Polls run on the thread that owns the chain, while it blocks in Wait with
+// the chain's Context or a Context nested in it, so the object is never
+// called from another apartment. The channel holds one value: if it has not
+// been received by the next change, it is replaced by the newer value.
+//
+// The channel is always closed in the end: when the returned stop function
+// is called, when the chain's Context is released or done, or when reading
+// the property fails. stop returns the error that ended the observation, or
+// nil if it was stopped or its Context was released.
+func (c *chain) Observe(prop string, interval time.Duration) (<-chan interface{}, func() error) {
+	o := &observer{chain: c, prop: prop, interval: interval, ch: make(chan interface{}, 1)}
+	sc, _ := c.ctx.(*sugarContext)
+	switch {
+	case c.err != nil:
+		o.finish(c.err)
+	case c.disp == nil:
+		o.finish(errors.New("dispatch is nil"))
+	case sc == nil:
+		o.finish(errors.New("Observe needs a chain tracked by a Context"))
+	default:
+		sc.mu.Lock()
+		sc.observers = append(sc.observers, o)
+		sc.mu.Unlock()
+	}
+	stop := func() error {
+		o.finish(nil)
+		return o.error()
+	}
+	return o.ch, stop
+}
+
+// observer is a property watched with Observe.
+type observer struct {
+	chain    *chain
+	prop     string
+	interval time.Duration
+	// next, last and primed are only used by the polling thread.
+	next   time.Time
+	last   interface{}
+	primed bool
+
+	mu     sync.Mutex
+	ch     chan interface{}
+	closed bool
+	err    error
+}
+
+// poll reads the property if it is due, sends the value if it changed, and
+// returns when the next poll is due. A failed read finishes the observer.
+func (o *observer) poll(now time.Time) time.Time {
+	if now.Before(o.next) {
+		return o.next
+	}
+	o.next = now.Add(o.interval)
+
+	result, err := o.chain.invoke(OpGet, o.prop, ole.DISPATCH_PROPERTYGET, nil)
+	if err != nil {
+		o.finish(err)
+		return o.next
+	}
+	isObject := result.VT == ole.VT_DISPATCH || result.VT == ole.VT_UNKNOWN
+	val := variantValue(result)
+	result.Clear()
+	if isObject {
+		o.finish(wrapErr(OpGet, o.prop, errors.New("property holds an object, which cannot be observed")))
+		return o.next
+	}
+
+	if o.primed && !reflect.DeepEqual(val, o.last) {
+		o.send(val)
+	}
+	o.last, o.primed = val, true
+	return o.next
+}
+
+// send replaces any value not received yet with val.
+func (o *observer) send(val interface{}) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return
+	}
+	select {
+	case <-o.ch:
+	default:
+	}
+	o.ch <- val
+}
+
+// finish closes the channel, recording err, unless it is already closed.
+func (o *observer) finish(err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return
+	}
+	o.closed, o.err = true, err
+	close(o.ch)
+}
+
+func (o *observer) done() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.closed
+}
+
+func (o *observer) error() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.err
+}
+
+// pollObservers runs the due polls of the observers of c and of the Contexts
+// enclosing it, and returns how long until the next poll is due, or 0 if
+// nothing is observed.
+func (c *sugarContext) pollObservers() time.Duration {
+	now := time.Now()
+	var next time.Time
+	for s := c; s != nil; s, _ = s.Context.Value(currentCtxKey{}).(*sugarContext) {
+		s.mu.Lock()
+		observers := append([]*observer(nil), s.observers...)
+		s.mu.Unlock()
+
+		ctxErr := s.Err()
+		for _, o := range observers {
+			if ctxErr != nil {
+				o.finish(ctxErr)
+			}
+			if o.done() {
+				s.removeObserver(o)
+				continue
+			}
+			if due := o.poll(now); next.IsZero() || due.Before(next) {
+				next = due
+			}
+		}
+	}
+	if next.IsZero() {
+		return 0
+	}
+	return max(time.Until(next), 0)
+}
+
+// observing reports whether c or an enclosing Context has observers.
+func (c *sugarContext) observing() bool {
+	for s := c; s != nil; s, _ = s.Context.Value(currentCtxKey{}).(*sugarContext) {
+		s.mu.Lock()
+		n := len(s.observers)
+		s.mu.Unlock()
+		if n > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *sugarContext) removeObserver(o *observer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, obs := range c.observers {
+		if obs == o {
+			c.observers = append(c.observers[:i], c.observers[i+1:]...)
+			return
+		}
+	}
+}
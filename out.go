@@ -0,0 +1,188 @@
+//go:build windows
+
+package sugar
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/go-ole/go-ole"
+)
+
+// CallOut calls a method whose [out] or [in, out] parameters are given as Go
+// pointers. Each pointer's value is sent by reference, and once the call
+// returns the value the server left there is written back through the
+// pointer and also returned, one entry per pointer argument in order.
+func (c *chain) CallOut(method string, params ...interface{}) ([]interface{}, Chain) {
+	if c.err != nil {
+		return nil, &chain{err: c.err, ctx: c.ctx}
+	}
+	if c.disp == nil {
+		return nil, &chain{err: fmt.Errorf("dispatch is nil"), ctx: c.ctx}
+	}
+	result, outs, err := c.invokeOut(method, params)
+	if err != nil {
+		return nil, &chain{err: err, ctx: c.ctx}
+	}
+	values := make([]interface{}, len(outs))
+	for i, out := range outs {
+		values[i] = out.ptr.Elem().Interface()
+	}
+	return values, c.handleResult(result, nil)
+}
+
+// outArg is the storage of a by-reference argument of CallOut. The server
+// reads and writes value through ref.
+type outArg struct {
+	ptr   reflect.Value
+	value ole.VARIANT
+	ref   ole.VARIANT
+}
+
+// newOutArg prepares p for passing by reference, or returns nil if p is not
+// an out-parameter.
+func newOutArg(p interface{}) (*outArg, error) {
+	switch p.(type) {
+	case *ole.IDispatch, *ole.VARIANT:
+		return nil, nil
+	}
+	rv := reflect.ValueOf(p)
+	if rv.Kind() != reflect.Pointer {
+		return nil, nil
+	}
+	if rv.IsNil() {
+		return nil, fmt.Errorf("nil %T", p)
+	}
+
+	a := &outArg{ptr: rv}
+	switch v := p.(type) {
+	case *interface{}:
+		value, err := toVariant(*v)
+		if err != nil {
+			return nil, err
+		}
+		a.value = value
+		a.ref = ole.NewVariant(ole.VT_VARIANT|ole.VT_BYREF, int64(uintptr(unsafe.Pointer(&a.value))))
+		return a, nil
+	case *int:
+		if *v < math.MinInt32 || *v > math.MaxInt32 {
+			return nil, fmt.Errorf("value %d overflows a 32-bit out-parameter", *v)
+		}
+		a.value = ole.NewVariant(ole.VT_I4, int64(*v))
+	case *string, *bool, *int16, *int32, *float32, *float64, *time.Time:
+		value, err := toVariant(rv.Elem().Interface())
+		if err != nil {
+			return nil, err
+		}
+		a.value = value
+	default:
+		return nil, fmt.Errorf("unsupported out-parameter type %T", p)
+	}
+	// A typed reference points at the value inside the VARIANT.
+	a.ref = ole.NewVariant(a.value.VT|ole.VT_BYREF, int64(uintptr(unsafe.Pointer(&a.value.Val))))
+	return a, nil
+}
+
+// readBack stores the value left by the server through the Go pointer. An
+// object is returned as a Chain tracked by ctx.
+func (a *outArg) readBack(ctx Context) error {
+	var v interface{}
+	if a.value.VT == ole.VT_DISPATCH {
+		ch := From(a.value.ToIDispatch())
+		if ctx != nil {
+			ctx.Track(ch)
+		}
+		v = ch
+	} else {
+		v = variantValue(&a.value)
+	}
+
+	elem := a.ptr.Elem()
+	out, err := coerceValue(v, elem.Type())
+	if err != nil {
+		return err
+	}
+	elem.Set(out)
+	return nil
+}
+
+// invokeOut invokes method with params, passing pointer arguments by
+// reference, and writes the results back through the pointers.
+func (c *chain) invokeOut(method string, params []interface{}) (_ *ole.VARIANT, _ []*outArg, err error) {
+	if err := c.preInvoke(OpCall, method); err != nil {
+		return nil, nil, err
+	}
+	dispid, err := c.dispID(method)
+	if err != nil {
+		return nil, nil, c.suggestMember(wrapErr(OpCall, method, err))
+	}
+	if done := traceStart(OpCall, method, params); done != nil {
+		defer func() { done(err) }()
+	}
+
+	args, release, err := prepareParams(params)
+	if err != nil {
+		return nil, nil, wrapErr(OpCall, method, err)
+	}
+	defer release()
+
+	// Arguments are passed in reverse order.
+	var outs []*outArg
+	vars := make([]ole.VARIANT, len(args))
+	for i, arg := range args {
+		n := len(args) - i - 1
+		out, err := newOutArg(arg)
+		if err != nil {
+			return nil, nil, wrapErr(OpCall, method, fmt.Errorf("param %d: %w", i, err))
+		}
+		if out != nil {
+			defer ole.VariantClear(&out.value)
+			outs = append(outs, out)
+			vars[n] = out.ref
+			continue
+		}
+		v, owned, err := argVariant(arg)
+		if err != nil {
+			return nil, nil, wrapErr(OpCall, method, fmt.Errorf("param %d: %w", i, err))
+		}
+		vars[n] = v
+		if owned {
+			defer ole.VariantClear(&vars[n])
+		}
+	}
+
+	var dp dispParams
+	if len(vars) > 0 {
+		dp.rgvarg = &vars[0]
+		dp.cArgs = uint32(len(vars))
+	}
+
+	result := new(ole.VARIANT)
+	ole.VariantInit(result)
+	var info excepInfo
+	hr, _, _ := syscall.SyscallN(c.disp.VTable().Invoke,
+		uintptr(unsafe.Pointer(c.disp)),
+		uintptr(dispid),
+		uintptr(unsafe.Pointer(ole.IID_NULL)),
+		uintptr(ole.GetUserDefaultLCID()),
+		uintptr(ole.DISPATCH_METHOD),
+		uintptr(unsafe.Pointer(&dp)),
+		uintptr(unsafe.Pointer(result)),
+		uintptr(unsafe.Pointer(&info)),
+		0)
+	if hr != 0 {
+		return nil, nil, wrapErr(OpCall, method, info.error(hr))
+	}
+
+	for i, out := range outs {
+		if err := out.readBack(c.ctx); err != nil {
+			result.Clear()
+			return nil, nil, wrapErr(OpCall, method, fmt.Errorf("out-parameter %d: %w", i, err))
+		}
+	}
+	return result, outs, nil
+}
@@ -0,0 +1,180 @@
+//go:build windows
+
+// Package outlook provides typed wrappers over the Outlook.Application object
+// model for composing and sending mail, built on sugar.Chain in the same way
+// as the excel package.
+package outlook
+
+import (
+	"github.com/xll-gen/sugar"
+)
+
+// OlDefaultFolders identifies a default folder of the current profile.
+type OlDefaultFolders int
+
+const (
+	OlFolderDeletedItems OlDefaultFolders = 3
+	OlFolderOutbox       OlDefaultFolders = 4
+	OlFolderSentMail     OlDefaultFolders = 5
+	OlFolderInbox        OlDefaultFolders = 6
+	OlFolderDrafts       OlDefaultFolders = 16
+)
+
+// olMailItem is the OlItemType of a mail message.
+const olMailItem = 0
+
+// Application represents the Outlook.Application object.
+// It is the root of the Outlook object model.
+type Application interface {
+	sugar.Chain
+	// Session returns the MAPI namespace of the current profile.
+	Session() Namespace
+	// CreateMail creates a new, unsent mail message.
+	CreateMail() MailItem
+	// Quit quits Outlook.
+	Quit() error
+}
+
+type application struct {
+	sugar.Chain
+}
+
+func (a *application) Session() Namespace {
+	return &namespace{a.Call("GetNamespace", "MAPI")}
+}
+
+func (a *application) CreateMail() MailItem {
+	return &mailItem{a.Call("CreateItem", olMailItem)}
+}
+
+func (a *application) Quit() error {
+	return a.Call("Quit").Err()
+}
+
+// NewApplication creates a new Outlook instance, or attaches to the running
+// one: Outlook only allows a single instance per user.
+func NewApplication(ctx sugar.Context) Application {
+	return &application{ctx.Create("Outlook.Application")}
+}
+
+// GetApplication attaches to a running Outlook instance.
+func GetApplication(ctx sugar.Context) Application {
+	return &application{ctx.GetActive("Outlook.Application")}
+}
+
+// Namespace represents the MAPI namespace, which gives access to the
+// folders and identity of the profile.
+type Namespace interface {
+	sugar.Chain
+	// CurrentUser returns the display name of the logged-on user.
+	CurrentUser() (string, error)
+	// GetDefaultFolder returns a default folder such as OlFolderInbox.
+	GetDefaultFolder(folder OlDefaultFolders) Folder
+}
+
+type namespace struct {
+	sugar.Chain
+}
+
+func (n *namespace) CurrentUser() (string, error) {
+	return n.Get("CurrentUser").Get("Name").GetString()
+}
+
+func (n *namespace) GetDefaultFolder(folder OlDefaultFolders) Folder {
+	return &outlookFolder{n.Call("GetDefaultFolder", int(folder))}
+}
+
+// Folder represents a mail folder.
+type Folder interface {
+	sugar.Chain
+	// Name returns the display name of the folder.
+	Name() (string, error)
+	// Count returns the number of items in the folder.
+	Count() (int, error)
+}
+
+type outlookFolder struct {
+	sugar.Chain
+}
+
+func (f *outlookFolder) Name() (string, error) {
+	return f.Get("Name").GetString()
+}
+
+func (f *outlookFolder) Count() (int, error) {
+	n, err := f.Get("Items").Get("Count").GetInt()
+	return int(n), err
+}
+
+// MailItem represents a mail message.
+type MailItem interface {
+	sugar.Chain
+	// SetTo sets the recipients, separated by semicolons.
+	SetTo(to string) MailItem
+	// SetCC sets the carbon copy recipients, separated by semicolons.
+	SetCC(cc string) MailItem
+	// SetSubject sets the subject.
+	SetSubject(subject string) MailItem
+	// Subject returns the subject.
+	Subject() (string, error)
+	// SetBody sets the plain text body.
+	SetBody(body string) MailItem
+	// SetHTMLBody sets the HTML body, which replaces the plain text one.
+	SetHTMLBody(html string) MailItem
+	// AddAttachment attaches the file at path.
+	AddAttachment(path string) MailItem
+	// Save saves the message to the Drafts folder.
+	Save() error
+	// Send sends the message. The MailItem cannot be used afterwards.
+	Send() error
+	// Display opens the message in an inspector window without blocking.
+	Display() error
+}
+
+type mailItem struct {
+	sugar.Chain
+}
+
+func (m *mailItem) SetTo(to string) MailItem {
+	return &mailItem{m.Put("To", to)}
+}
+
+func (m *mailItem) SetCC(cc string) MailItem {
+	return &mailItem{m.Put("CC", cc)}
+}
+
+func (m *mailItem) SetSubject(subject string) MailItem {
+	return &mailItem{m.Put("Subject", subject)}
+}
+
+func (m *mailItem) Subject() (string, error) {
+	return m.Get("Subject").GetString()
+}
+
+func (m *mailItem) SetBody(body string) MailItem {
+	return &mailItem{m.Put("Body", body)}
+}
+
+func (m *mailItem) SetHTMLBody(html string) MailItem {
+	return &mailItem{m.Put("HTMLBody", html)}
+}
+
+func (m *mailItem) AddAttachment(path string) MailItem {
+	att := m.Get("Attachments").Call("Add", path)
+	if att.Err() != nil {
+		return &mailItem{att}
+	}
+	return m
+}
+
+func (m *mailItem) Save() error {
+	return m.Call("Save").Err()
+}
+
+func (m *mailItem) Send() error {
+	return m.Call("Send").Err()
+}
+
+func (m *mailItem) Display() error {
+	return m.Call("Display", false).Err()
+}
@@ -0,0 +1,67 @@
+//go:build windows
+
+package outlook_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xll-gen/sugar"
+	"github.com/xll-gen/sugar/outlook"
+)
+
+func ExampleMailItem_Send() {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := outlook.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			return err
+		}
+
+		err := app.CreateMail().
+			SetTo("someone@example.com").
+			SetSubject("Monthly report").
+			SetBody("Please find the report attached.").
+			AddAttachment(`C:\reports\report.xlsx`).
+			Send()
+		if err != nil {
+			fmt.Println("Send failed:", err)
+		}
+		return err
+	})
+}
+
+func TestOutlook_MailItem(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := outlook.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Outlook not installed:", err)
+			return nil
+		}
+
+		path := filepath.Join(t.TempDir(), "note.txt")
+		if err := os.WriteFile(path, []byte("attached"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		mail := app.CreateMail()
+		// Discard the draft instead of sending it (olDiscard).
+		defer mail.Call("Close", 1)
+
+		err := mail.SetTo("someone@example.com").
+			SetSubject("sugar test").
+			SetBody("body").
+			AddAttachment(path).Err()
+		if err != nil {
+			t.Fatalf("composing failed: %v", err)
+		}
+		if subject, err := mail.Subject(); err != nil || subject != "sugar test" {
+			t.Errorf("expected subject sugar test, got %q (%v)", subject, err)
+		}
+		if n, err := mail.Get("Attachments").Get("Count").GetInt(); err != nil || n != 1 {
+			t.Errorf("expected 1 attachment, got %d (%v)", n, err)
+		}
+		return nil
+	})
+}
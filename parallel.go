@@ -0,0 +1,74 @@
+//go:build windows
+
+package sugar
+
+import (
+	"context"
+	"errors"
+)
+
+// ParallelDo processes items with n worker goroutines, each initializing COM
+// on its own OS thread as with Go. See ParallelDoWith.
+func ParallelDo[T any](n int, items []T, fn func(ctx Context, item T) error) error {
+	return ParallelDoWith(With(context.Background()), n, items, fn)
+}
+
+// ParallelDoWith processes items with n worker goroutines started like r.Go,
+// so each worker has its own COM scope configured by r. Every item runs in a
+// nested scope of its worker, which releases the chains created for it
+// before the next item.
+//
+// A timeout set on r with WithTimeout bounds the whole call, not each
+// worker. Once it passes or the parent context of r is done, workers stop
+// taking items and the context's error is included in the result.
+// ParallelDoWith waits for all workers and returns the errors of the failed
+// items joined together.
+func ParallelDoWith[T any](r *Runner, n int, items []T, fn func(ctx Context, item T) error) error {
+	parent := r.parent
+	if parent == nil {
+		parent = context.Background()
+	}
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		parent, cancel = context.WithTimeout(parent, r.timeout)
+		defer cancel()
+	}
+	// Workers share the deadline above instead of starting their own.
+	worker := *r
+	worker.parent, worker.timeout = parent, 0
+	if n > len(items) {
+		n = len(items)
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	feed := make(chan T, len(items))
+	for _, item := range items {
+		feed <- item
+	}
+	close(feed)
+
+	results := make([]<-chan error, n)
+	for i := range results {
+		results[i] = worker.GoResult(func(ctx Context) error {
+			var errs []error
+			for item := range feed {
+				if ctx.Err() != nil {
+					break
+				}
+				if err := ctx.Do(func(ctx Context) error { return fn(ctx, item) }); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			return errors.Join(errs...)
+		})
+	}
+
+	errs := make([]error, 0, n+1)
+	for _, done := range results {
+		errs = append(errs, <-done)
+	}
+	errs = append(errs, parent.Err())
+	return errors.Join(errs...)
+}
@@ -0,0 +1,168 @@
+//go:build windows
+
+package sugar
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/go-ole/go-ole"
+)
+
+// Missing stands for an omitted optional argument, so that later positional
+// arguments can be given while skipping earlier ones, as with VBA's
+// Foo a, , c:
+//
+//	wbs.Call("Open", path, sugar.Missing, true) // UpdateLinks omitted
+//
+// It is passed as a VT_ERROR VARIANT with DISP_E_PARAMNOTFOUND. Passing nil
+// instead sends VT_NULL, which most servers reject or treat as a value.
+var Missing = missingArg{}
+
+type missingArg struct{}
+
+// dispEParamNotFound is the SCODE COM uses for omitted arguments.
+const dispEParamNotFound = 0x80020004
+
+// invoke performs a named IDispatch invocation on behalf of op, marshalling
+// the parameters with prepareParams and wrapping failures in a ComError.
+// The member is resolved with dispID. Chains of the Context whose
+// ReleaseAfter deadline has passed are released first. If the Context is
+// done, the call is not made and its error is returned instead.
+func (c *chain) invoke(op, member string, flags int16, params []interface{}) (*ole.VARIANT, error) {
+	if err := c.preInvoke(op, member); err != nil {
+		return nil, err
+	}
+	dispid, err := c.dispID(member)
+	if err != nil {
+		return nil, c.suggestMember(wrapErr(op, member, err))
+	}
+	result, err := c.invokeID(op, member, dispid, flags, params)
+	if err != nil {
+		return nil, c.suggestMember(err)
+	}
+	return result, nil
+}
+
+// preInvoke runs the checks and housekeeping that precede every invocation.
+func (c *chain) preInvoke(op, member string) error {
+	if err := c.ctxErr(); err != nil {
+		return wrapErr(op, member, err)
+	}
+	if sc, ok := c.ctx.(*sugarContext); ok {
+		if sc.opts.checkThreads && sc.opts.apartment != MTA {
+			if err := c.CheckThread(); err != nil {
+				return wrapErr(op, member, err)
+			}
+		}
+		sc.releasePending()
+		if c.disp == nil {
+			return wrapErr(op, member, errors.New("dispatch is nil"))
+		}
+	}
+	return nil
+}
+
+// invokeID is invoke for a member that is already resolved to dispid. The
+// member name is only used for error reporting.
+func (c *chain) invokeID(op, member string, dispid int32, flags int16, params []interface{}) (result *ole.VARIANT, err error) {
+	if done := traceStart(op, member, params); done != nil {
+		defer func() { done(err) }()
+	}
+
+	args, release, err := prepareParams(params)
+	if err != nil {
+		return nil, wrapErr(op, member, err)
+	}
+	defer release()
+
+	result, err = c.disp.Invoke(dispid, flags, args...)
+	return result, wrapErr(op, member, err)
+}
+
+// prepareParams converts parameters that go-ole cannot marshal itself. Chain
+// arguments are replaced by their underlying *ole.IDispatch, holding an extra
+// reference for the duration of the call, time.Time values become
+// VT_DATE VARIANTs (see timeToOADate), Currency and *big.Rat values become
+// VT_CY and VT_DECIMAL VARIANTs, and Missing becomes an omitted
+// argument. The returned function releases
+// those references and must be called once the invocation has completed.
+func prepareParams(params []interface{}) ([]interface{}, func(), error) {
+	var refs []*ole.IDispatch
+	release := func() {
+		for _, disp := range refs {
+			disp.Release()
+		}
+	}
+
+	var args []interface{}
+	for i, p := range params {
+		var arg interface{}
+		switch v := p.(type) {
+		case Chain:
+			disp, err := v.Store()
+			if err != nil {
+				release()
+				return nil, func() {}, fmt.Errorf("param %d: %w", i, err)
+			}
+			refs = append(refs, disp)
+			arg = disp
+		case missingArg:
+			omitted := ole.NewVariant(ole.VT_ERROR, dispEParamNotFound)
+			arg = &omitted
+		case time.Time:
+			// go-ole would send a string; a by-reference VARIANT carries a real date.
+			date := ole.NewVariant(ole.VT_DATE, int64(math.Float64bits(timeToOADate(v))))
+			arg = &date
+		case Currency, *big.Rat:
+			// go-ole marshals neither VT_CY nor VT_DECIMAL.
+			value, err := toVariant(v)
+			if err != nil {
+				release()
+				return nil, func() {}, fmt.Errorf("param %d: %w", i, err)
+			}
+			arg = &value
+		default:
+			continue
+		}
+		if args == nil {
+			args = append([]interface{}(nil), params...)
+		}
+		args[i] = arg
+	}
+	if args == nil {
+		args = params
+	}
+	return args, release, nil
+}
+
+// oleEpoch is day zero of the OLE Automation date format.
+var oleEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// timeToOADate converts t to an OLE Automation date: the number of days since
+// 1899-12-30, with the time of day as the fraction. OLE dates carry no time
+// zone, so the wall clock of t in its own location is stored as is; convert t
+// with In beforehand to store it in another zone.
+func timeToOADate(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	secs := wall.Unix() - oleEpoch.Unix()
+	days := math.Floor(float64(secs) / 86400)
+	frac := (float64(secs-int64(days)*86400) + float64(wall.Nanosecond())/1e9) / 86400
+	// Before the epoch, the integral part counts days backwards while the
+	// fraction still counts time forwards from midnight.
+	if days < 0 && frac > 0 {
+		return days - frac
+	}
+	return days + frac
+}
+
+// oaDateToTime converts an OLE Automation date to a time.Time in UTC whose wall
+// clock matches the stored value, rounded to the millisecond.
+func oaDateToTime(d float64) time.Time {
+	days := math.Trunc(d)
+	ms := math.Round(math.Abs(d-days) * 86400e3)
+	return oleEpoch.AddDate(0, 0, int(days)).Add(time.Duration(ms) * time.Millisecond)
+}
@@ -0,0 +1,144 @@
+//go:build windows
+
+package sugar
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/go-ole/go-ole"
+	"github.com/go-ole/go-ole/oleutil"
+)
+
+// objidNativeOM asks AccessibleObjectFromWindow for the native object model
+// of an Office document window instead of its accessibility object.
+const objidNativeOM = 0xFFFFFFF0
+
+var (
+	moduser32 = syscall.NewLazyDLL("user32.dll")
+	modoleacc = syscall.NewLazyDLL("oleacc.dll")
+
+	procEnumWindows                = moduser32.NewProc("EnumWindows")
+	procEnumChildWindows           = moduser32.NewProc("EnumChildWindows")
+	procGetWindowThreadProcessId   = moduser32.NewProc("GetWindowThreadProcessId")
+	procAccessibleObjectFromWindow = modoleacc.NewProc("AccessibleObjectFromWindow")
+
+	// Callbacks are a limited resource, so a single one collects handles into
+	// enumHwnds under enumMu.
+	enumMu       sync.Mutex
+	enumHwnds    []uintptr
+	enumCallback = syscall.NewCallback(func(hwnd, _ uintptr) uintptr {
+		enumHwnds = append(enumHwnds, hwnd)
+		return 1
+	})
+)
+
+// windowsOf returns the top-level windows when parent is 0, or all descendants
+// of parent otherwise.
+func windowsOf(parent uintptr) []uintptr {
+	enumMu.Lock()
+	defer enumMu.Unlock()
+
+	enumHwnds = nil
+	if parent == 0 {
+		procEnumWindows.Call(enumCallback, 0)
+	} else {
+		procEnumChildWindows.Call(parent, enumCallback, 0)
+	}
+	hwnds := enumHwnds
+	enumHwnds = nil
+	return hwnds
+}
+
+// WindowProcessID returns the ID of the process that owns the window, such as
+// the value of Excel's Application.Hwnd.
+func WindowProcessID(hwnd uintptr) (int, error) {
+	var pid uint32
+	tid, _, err := procGetWindowThreadProcessId.Call(hwnd, uintptr(unsafe.Pointer(&pid)))
+	if tid == 0 {
+		return 0, err
+	}
+	return int(pid), nil
+}
+
+// GetActiveByPID attaches to the instance of an Office-style automation server
+// running in the given process, rather than to whichever instance is
+// registered in the Running Object Table.
+//
+// The object is reached through the native object model of one of the
+// process's document windows (for Excel, a workbook window), whose
+// Application property is returned. The instance must therefore have at least
+// one document window open. The Application must come from the type library
+// that registers progID's class, so that a process running another server is
+// rejected.
+func GetActiveByPID(progID string, pid int) Chain {
+	clsid, err := ole.CLSIDFromProgID(progID)
+	if err != nil {
+		return &chain{err: err}
+	}
+	mismatch := false
+
+	for _, top := range windowsOf(0) {
+		if owner, err := WindowProcessID(top); err != nil || owner != pid {
+			continue
+		}
+		for _, hwnd := range append([]uintptr{top}, windowsOf(top)...) {
+			var disp *ole.IDispatch
+			hr, _, _ := procAccessibleObjectFromWindow.Call(
+				hwnd,
+				objidNativeOM,
+				uintptr(unsafe.Pointer(ole.IID_IDispatch)),
+				uintptr(unsafe.Pointer(&disp)))
+			if hr != 0 || disp == nil {
+				continue
+			}
+
+			app, err := oleutil.GetProperty(disp, "Application")
+			disp.Release()
+			if err != nil {
+				continue
+			}
+			if app.VT != ole.VT_DISPATCH {
+				app.Clear()
+				continue
+			}
+			if !describesClass(app.ToIDispatch(), clsid) {
+				app.Clear()
+				mismatch = true
+				continue
+			}
+			return &chain{disp: app.ToIDispatch(), thread: ownerThread()}
+		}
+	}
+
+	if mismatch {
+		return &chain{err: fmt.Errorf("process %d does not run a %s object", pid, progID)}
+	}
+	return &chain{err: fmt.Errorf("no %s object model window found in process %d", progID, pid)}
+}
+
+// describesClass reports whether the type library describing disp also
+// describes the class clsid, as the library of an automation server describes
+// both its Application object and the class registered for its ProgID.
+func describesClass(disp *ole.IDispatch, clsid *ole.GUID) bool {
+	info, err := disp.GetTypeInfo()
+	if err != nil {
+		return false
+	}
+	defer info.Release()
+	var lib unsafe.Pointer
+	var index uint32
+	if hr := comCall(unsafe.Pointer(info), vtblGetContainingTypeLib, uintptr(unsafe.Pointer(&lib)), uintptr(unsafe.Pointer(&index))); hr != 0 {
+		return false
+	}
+	defer releaseUnknown(lib)
+
+	var class unsafe.Pointer
+	if hr := comCall(lib, vtblGetTypeInfoOfGUID, uintptr(unsafe.Pointer(clsid)), uintptr(unsafe.Pointer(&class))); hr != 0 {
+		return false
+	}
+	releaseUnknown(class)
+	return true
+}
@@ -0,0 +1,116 @@
+//go:build windows
+
+package sugar
+
+import (
+	"context"
+	"time"
+	"unsafe"
+)
+
+var (
+	procPeekMessageW                = moduser32.NewProc("PeekMessageW")
+	procTranslateMessage            = moduser32.NewProc("TranslateMessage")
+	procDispatchMessageW            = moduser32.NewProc("DispatchMessageW")
+	procMsgWaitForMultipleObjectsEx = moduser32.NewProc("MsgWaitForMultipleObjectsEx")
+)
+
+const (
+	pmRemove           = 0x0001
+	qsAllInput         = 0x04FF
+	mwmoInputAvailable = 0x0004
+)
+
+// pumpSlice bounds each wait for messages, so that Wait notices the end of its
+// context promptly.
+const pumpSlice = 50 * time.Millisecond
+
+type msg struct {
+	hwnd     uintptr
+	message  uint32
+	wParam   uintptr
+	lParam   uintptr
+	time     uint32
+	pt       [2]int32
+	lPrivate uint32
+}
+
+// WithMessagePump makes Do dispatch the window messages of its thread at two
+// points only: while the function blocks in Wait, and once more when the
+// function returns. It does not run a pump in the background, so messages
+// still wait while the function computes without calling Wait. During a COM
+// call the messages are delivered by COM itself, with or without this option.
+//
+// STA servers that call back into the client, connection-point events in
+// particular, need the pump to reach the client between its own calls: call
+// Wait wherever the function idles, such as in an event loop. Pumping stops
+// when Do returns.
+func (r *Runner) WithMessagePump() *Runner {
+	r.pump = true
+	return r
+}
+
+// Wait blocks for d or until ctx is done, in which case it returns ctx.Err().
+// Meanwhile it polls the properties watched with Observe through ctx or a
+// Context enclosing it. Inside a Do with WithMessagePump it also dispatches
+// the thread's window messages and releases the chains whose ReleaseAfter
+// deadline has passed. Otherwise it simply sleeps.
+func Wait(ctx context.Context, d time.Duration) error {
+	sc, _ := ctx.Value(currentCtxKey{}).(*sugarContext)
+	if sc == nil || !sc.opts.pump && !sc.observing() {
+		return sleep(ctx, d)
+	}
+
+	deadline := time.Now().Add(d)
+	for {
+		if sc.opts.pump {
+			pumpMessages()
+			sc.releasePending()
+		}
+		nextPoll := sc.pollObservers()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil
+		}
+		if remaining > pumpSlice {
+			remaining = pumpSlice
+		}
+		if nextPoll > 0 && nextPoll < remaining {
+			remaining = nextPoll
+		}
+		if !sc.opts.pump {
+			if err := sleep(ctx, remaining); err != nil {
+				return err
+			}
+			continue
+		}
+		procMsgWaitForMultipleObjectsEx.Call(0, 0, uintptr(remaining.Milliseconds()), qsAllInput, mwmoInputAvailable)
+	}
+}
+
+// sleep blocks for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// pumpMessages dispatches every message queued for the current thread.
+func pumpMessages() {
+	var m msg
+	for {
+		if ok, _, _ := procPeekMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0, pmRemove); ok == 0 {
+			return
+		}
+		procTranslateMessage.Call(uintptr(unsafe.Pointer(&m)))
+		procDispatchMessageW.Call(uintptr(unsafe.Pointer(&m)))
+	}
+}
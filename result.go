@@ -0,0 +1,54 @@
+//go:build windows
+
+package sugar
+
+// Result holds the outcome of a terminal read, allowing the value and the
+// error to be handled in a functional style.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// AsResult reads a property from ch and converts it to T.
+func AsResult[T any](ch Chain, prop string) Result[T] {
+	v, err := ch.Get(prop).Value()
+	if err != nil {
+		return Result[T]{Err: err}
+	}
+	val, err := coerce[T](v)
+	return Result[T]{Value: val, Err: err}
+}
+
+// ValueAs returns the last result of c converted to T, with the conversions
+// of GetInt and GetFloat for numbers and support for named types through
+// their underlying kind. It returns the zero T and an error if the value
+// cannot be represented as T.
+func ValueAs[T any](c Chain) (T, error) {
+	v, err := c.Value()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return coerce[T](v)
+}
+
+// OrElse returns the value, or def if the read failed.
+func (r Result[T]) OrElse(def T) T {
+	if r.Err != nil {
+		return def
+	}
+	return r.Value
+}
+
+// Unwrap returns the value and the error.
+func (r Result[T]) Unwrap() (T, error) {
+	return r.Value, r.Err
+}
+
+// Must returns the value and panics if the read failed.
+func (r Result[T]) Must() T {
+	if r.Err != nil {
+		panic(r.Err)
+	}
+	return r.Value
+}
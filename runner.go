@@ -4,15 +4,19 @@ package sugar
 
 import (
 	"context"
-	"runtime"
-
-	"github.com/go-ole/go-ole"
+	"runtime/debug"
+	"time"
 )
 
 // Runner configures the execution environment for COM operations.
 type Runner struct {
 	parent    context.Context
 	forceInit bool
+	apartment ApartmentModel
+	pump      bool
+	rawPanics bool
+	timeout   time.Duration
+	security  *securityLevels
 }
 
 // With returns a new Runner with the specified parent context.
@@ -20,7 +24,26 @@ func With(ctx context.Context) *Runner {
 	return &Runner{parent: ctx}
 }
 
+// WithRawPanics lets panics from the function passed to Do propagate instead
+// of being returned as a *PanicError. Tracked chains are released and COM is
+// uninitialized either way.
+func (r *Runner) WithRawPanics() *Runner {
+	r.rawPanics = true
+	return r
+}
+
+// WithTimeout bounds the whole Do block to d. Once it passes, the Context
+// passed to fn is done and chains stop at the next COM call (see Context).
+// Do then returns context.DeadlineExceeded, even if fn ignored the failed
+// calls and returned nil.
+func (r *Runner) WithTimeout(d time.Duration) *Runner {
+	r.timeout = d
+	return r
+}
+
 // Do executes the provided function in the current goroutine.
+// A panic in fn is recovered and returned as a *PanicError, unless the Runner
+// was configured WithRawPanics.
 func (r *Runner) Do(fn func(ctx Context) error) (err error) {
 	if r.parent == nil {
 		r.parent = context.Background()
@@ -29,37 +52,94 @@ func (r *Runner) Do(fn func(ctx Context) error) (err error) {
 	isNested := !r.forceInit && r.parent.Value(activeSugarKey) != nil
 
 	if !isNested {
-		runtime.LockOSThread()
-		defer runtime.UnlockOSThread()
-
-		if err := ole.CoInitialize(0); err != nil {
+		leave, err := r.apartment.enter()
+		if err != nil {
 			return err
 		}
-		defer ole.CoUninitialize()
+		defer leave()
+		if r.security != nil {
+			if err := r.security.apply(); err != nil {
+				return err
+			}
+		}
 	}
 
-	innerStdCtx := context.WithValue(r.parent, activeSugarKey, true)
-	ctx := NewContext(innerStdCtx)
-	
+	parent := r.parent
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		parent, cancel = context.WithTimeout(parent, r.timeout)
+		defer cancel()
+		defer func() {
+			if err == nil && parent.Err() == context.DeadlineExceeded {
+				err = context.DeadlineExceeded
+			}
+		}()
+	}
+
+	innerStdCtx := context.WithValue(parent, activeSugarKey, true)
+	var opts []ContextOption
+	if r.pump {
+		opts = append(opts, func(o *contextOptions) { o.pump = true })
+	}
+	if !isNested {
+		opts = append(opts, func(o *contextOptions) { o.apartment = r.apartment })
+	}
+	ctx := NewContext(innerStdCtx, opts...)
+
 	defer func() {
+		if r.pump {
+			pumpMessages()
+		}
 		releaseErr := ctx.Release()
 		if err == nil {
 			err = releaseErr
 		}
 	}()
 
+	if r.rawPanics {
+		return fn(ctx)
+	}
+	return callRecover(fn, ctx)
+}
+
+// callRecover runs fn and converts a panic into a *PanicError. It recovers
+// before Do's deferred release, so teardown runs as on a normal return.
+func callRecover(fn func(ctx Context) error, ctx Context) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
 	return fn(ctx)
 }
 
-// Go executes the provided function in a new goroutine.
+// Go executes the provided function in a new goroutine. Its error is
+// discarded; use GoResult to wait for it.
 func (r *Runner) Go(fn func(ctx Context) error) {
+	r.GoResult(fn)
+}
+
+// GoResult executes the provided function in a new goroutine and returns a
+// channel that delivers the error Do would have returned, once the
+// goroutine's chains are released and COM is uninitialized, and is then
+// closed. Like Go, the goroutine always initializes COM on its own OS thread,
+// even when started from within a Do block.
+func (r *Runner) GoResult(fn func(ctx Context) error) <-chan error {
+	runner := &Runner{
+		parent:    r.parent,
+		forceInit: true,
+		apartment: r.apartment,
+		pump:      r.pump,
+		rawPanics: r.rawPanics,
+		timeout:   r.timeout,
+		security:  r.security,
+	}
+	done := make(chan error, 1)
 	go func() {
-		runner := &Runner{
-			parent:    r.parent,
-			forceInit: true,
-		}
-		_ = runner.Do(fn)
+		defer close(done)
+		done <- runner.Do(fn)
 	}()
+	return done
 }
 
 // Do executes the function with a Background context.
@@ -70,4 +150,10 @@ func Do(fn func(ctx Context) error) error {
 // Go executes the function in a new goroutine with a Background context.
 func Go(fn func(ctx Context) error) {
 	With(context.Background()).Go(fn)
-}
\ No newline at end of file
+}
+
+// GoResult executes the function in a new goroutine with a Background
+// context and returns a channel delivering its error.
+func GoResult(fn func(ctx Context) error) <-chan error {
+	return With(context.Background()).GoResult(fn)
+}
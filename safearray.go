@@ -0,0 +1,243 @@
+//go:build windows
+
+package sugar
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/go-ole/go-ole"
+)
+
+var (
+	modoleaut32 = syscall.NewLazyDLL("oleaut32.dll")
+
+	procSafeArrayGetDim     = modoleaut32.NewProc("SafeArrayGetDim")
+	procSafeArrayGetLBound  = modoleaut32.NewProc("SafeArrayGetLBound")
+	procSafeArrayGetUBound  = modoleaut32.NewProc("SafeArrayGetUBound")
+	procSafeArrayGetVartype = modoleaut32.NewProc("SafeArrayGetVartype")
+	procSafeArrayGetElement = modoleaut32.NewProc("SafeArrayGetElement")
+	procSafeArrayCreate     = modoleaut32.NewProc("SafeArrayCreate")
+	procSafeArrayPutElement = modoleaut32.NewProc("SafeArrayPutElement")
+)
+
+// safeArray wraps a SAFEARRAY for reading with multi-dimensional indices,
+// which go-ole's helpers do not support.
+type safeArray struct {
+	sa *ole.SafeArray
+	vt ole.VT
+	// lower and upper hold the bounds of each dimension, in dimension order.
+	lower, upper []int32
+}
+
+func newSafeArray(v *ole.VARIANT) (*safeArray, error) {
+	if v.VT&ole.VT_ARRAY == 0 {
+		return nil, fmt.Errorf("result is not an array (VT %d)", v.VT)
+	}
+	a := &safeArray{sa: *(**ole.SafeArray)(unsafe.Pointer(&v.Val))}
+
+	var vt uint16
+	if hr, _, _ := procSafeArrayGetVartype.Call(uintptr(unsafe.Pointer(a.sa)), uintptr(unsafe.Pointer(&vt))); hr != 0 {
+		return nil, ole.NewError(hr)
+	}
+	a.vt = ole.VT(vt)
+
+	dims, _, _ := procSafeArrayGetDim.Call(uintptr(unsafe.Pointer(a.sa)))
+	a.lower = make([]int32, dims)
+	a.upper = make([]int32, dims)
+	for d := range a.lower {
+		if hr, _, _ := procSafeArrayGetLBound.Call(uintptr(unsafe.Pointer(a.sa)), uintptr(d+1), uintptr(unsafe.Pointer(&a.lower[d]))); hr != 0 {
+			return nil, ole.NewError(hr)
+		}
+		if hr, _, _ := procSafeArrayGetUBound.Call(uintptr(unsafe.Pointer(a.sa)), uintptr(d+1), uintptr(unsafe.Pointer(&a.upper[d]))); hr != 0 {
+			return nil, ole.NewError(hr)
+		}
+	}
+	return a, nil
+}
+
+// len returns the number of elements along dimension d (0-based).
+func (a *safeArray) len(d int) int {
+	return int(a.upper[d] - a.lower[d] + 1)
+}
+
+// at returns the element at the given 0-based offsets, one per dimension.
+func (a *safeArray) at(offsets ...int) (*ole.VARIANT, error) {
+	indices := make([]int32, len(offsets))
+	for d, off := range offsets {
+		indices[d] = a.lower[d] + int32(off)
+	}
+
+	v := new(ole.VARIANT)
+	var ptr unsafe.Pointer
+	switch a.vt {
+	case ole.VT_VARIANT:
+		ptr = unsafe.Pointer(v)
+	case ole.VT_DECIMAL:
+		// A DECIMAL fills the whole VARIANT; its type is set afterwards.
+		ptr = unsafe.Pointer(v)
+	default:
+		// Scalars of up to eight bytes and BSTR pointers fit in Val.
+		v.VT = a.vt
+		ptr = unsafe.Pointer(&v.Val)
+	}
+	hr, _, _ := procSafeArrayGetElement.Call(
+		uintptr(unsafe.Pointer(a.sa)),
+		uintptr(unsafe.Pointer(&indices[0])),
+		uintptr(ptr))
+	if hr != 0 {
+		return nil, ole.NewError(hr)
+	}
+	if a.vt == ole.VT_DECIMAL {
+		v.VT = ole.VT_DECIMAL
+	}
+	return v, nil
+}
+
+// value returns the element at the given offsets as a Go value. With
+// errorsAsErrors, a VT_ERROR element is reported as a *CellError. Object
+// elements are rejected: the interface read from the array is released with
+// the copy of the element, so it cannot be handed out.
+func (a *safeArray) value(errorsAsErrors bool, offsets ...int) (interface{}, error) {
+	v, err := a.at(offsets...)
+	if err != nil {
+		return nil, err
+	}
+	defer v.Clear()
+	if v.VT == ole.VT_ERROR && errorsAsErrors {
+		return nil, &CellError{Code: uint32(v.Val)}
+	}
+	if v.VT == ole.VT_DISPATCH || v.VT == ole.VT_UNKNOWN {
+		return nil, fmt.Errorf("element %v holds an object, which arrays of values cannot return", offsets)
+	}
+	return variantValue(v), nil
+}
+
+// newGridVariant builds a VT_ARRAY|VT_VARIANT VARIANT holding a 1-based
+// two-dimensional SAFEARRAY with the contents of data, which must be
+// rectangular. The caller owns the result and must Clear it.
+func newGridVariant(data [][]interface{}) (*ole.VARIANT, error) {
+	if len(data) == 0 || len(data[0]) == 0 {
+		return nil, fmt.Errorf("grid is empty")
+	}
+	cols := len(data[0])
+	for r, row := range data {
+		if len(row) != cols {
+			return nil, fmt.Errorf("grid is not rectangular: row %d has %d columns, want %d", r, len(row), cols)
+		}
+	}
+
+	bounds := []ole.SafeArrayBound{
+		{Elements: uint32(len(data)), LowerBound: 1},
+		{Elements: uint32(cols), LowerBound: 1},
+	}
+	sa, _, err := procSafeArrayCreate.Call(uintptr(ole.VT_VARIANT), 2, uintptr(unsafe.Pointer(&bounds[0])))
+	if sa == 0 {
+		return nil, fmt.Errorf("SafeArrayCreate: %w", err)
+	}
+	result := ole.NewVariant(ole.VT_ARRAY|ole.VT_VARIANT, int64(sa))
+
+	for r, row := range data {
+		for c, cell := range row {
+			v, err := toVariant(cell)
+			if err != nil {
+				result.Clear()
+				return nil, fmt.Errorf("cell (%d, %d): %w", r, c, err)
+			}
+			// SafeArrayPutElement copies the VARIANT, so ours is cleared either way.
+			indices := [2]int32{int32(r + 1), int32(c + 1)}
+			hr, _, _ := procSafeArrayPutElement.Call(sa, uintptr(unsafe.Pointer(&indices[0])), uintptr(unsafe.Pointer(&v)))
+			v.Clear()
+			if hr != 0 {
+				result.Clear()
+				return nil, ole.NewError(hr)
+			}
+		}
+	}
+	return &result, nil
+}
+
+// newSliceVariant builds a VT_ARRAY|VT_VARIANT VARIANT holding a 0-based
+// one-dimensional SAFEARRAY with the elements of data. The caller owns the
+// result and must Clear it.
+func newSliceVariant(data []interface{}) (*ole.VARIANT, error) {
+	bound := ole.SafeArrayBound{Elements: uint32(len(data))}
+	sa, _, err := procSafeArrayCreate.Call(uintptr(ole.VT_VARIANT), 1, uintptr(unsafe.Pointer(&bound)))
+	if sa == 0 {
+		return nil, fmt.Errorf("SafeArrayCreate: %w", err)
+	}
+	result := ole.NewVariant(ole.VT_ARRAY|ole.VT_VARIANT, int64(sa))
+
+	for i, elem := range data {
+		v, err := toVariant(elem)
+		if err != nil {
+			result.Clear()
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		index := int32(i)
+		hr, _, _ := procSafeArrayPutElement.Call(sa, uintptr(unsafe.Pointer(&index)), uintptr(unsafe.Pointer(&v)))
+		v.Clear()
+		if hr != 0 {
+			result.Clear()
+			return nil, ole.NewError(hr)
+		}
+	}
+	return &result, nil
+}
+
+// toVariant converts a Go scalar to a VARIANT. A string result owns a BSTR
+// and must be cleared.
+func toVariant(v interface{}) (ole.VARIANT, error) {
+	switch v := v.(type) {
+	case nil:
+		return ole.NewVariant(ole.VT_EMPTY, 0), nil
+	case string:
+		return ole.NewVariant(ole.VT_BSTR, int64(uintptr(unsafe.Pointer(ole.SysAllocStringLen(v))))), nil
+	case bool:
+		if v {
+			return ole.NewVariant(ole.VT_BOOL, 0xffff), nil
+		}
+		return ole.NewVariant(ole.VT_BOOL, 0), nil
+	case int:
+		return intVariant(int64(v)), nil
+	case int8:
+		return ole.NewVariant(ole.VT_I1, int64(v)), nil
+	case int16:
+		return ole.NewVariant(ole.VT_I2, int64(v)), nil
+	case int32:
+		return ole.NewVariant(ole.VT_I4, int64(v)), nil
+	case int64:
+		return intVariant(v), nil
+	case uint8:
+		return ole.NewVariant(ole.VT_UI1, int64(v)), nil
+	case uint16:
+		return ole.NewVariant(ole.VT_UI2, int64(v)), nil
+	case uint32:
+		return intVariant(int64(v)), nil
+	case float32:
+		return ole.NewVariant(ole.VT_R4, int64(math.Float32bits(v))), nil
+	case float64:
+		return ole.NewVariant(ole.VT_R8, int64(math.Float64bits(v))), nil
+	case time.Time:
+		return ole.NewVariant(ole.VT_DATE, int64(math.Float64bits(timeToOADate(v)))), nil
+	case Currency:
+		return ole.NewVariant(ole.VT_CY, int64(v)), nil
+	case *big.Rat:
+		return ratToDecimal(v)
+	default:
+		return ole.VARIANT{}, fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+// intVariant stores n as VT_I4 when it fits, and as a double otherwise, since
+// many automation servers (Excel among them) do not accept VT_I8.
+func intVariant(n int64) ole.VARIANT {
+	if n >= math.MinInt32 && n <= math.MaxInt32 {
+		return ole.NewVariant(ole.VT_I4, n)
+	}
+	return ole.NewVariant(ole.VT_R8, int64(math.Float64bits(float64(n))))
+}
@@ -0,0 +1,89 @@
+//go:build windows
+
+package sugar
+
+import (
+	"sync"
+
+	"github.com/go-ole/go-ole"
+)
+
+var procCoInitializeSecurity = modole32.NewProc("CoInitializeSecurity")
+
+// AuthLevel is the authentication level of COM calls (RPC_C_AUTHN_LEVEL).
+type AuthLevel uint32
+
+const (
+	AuthLevelDefault         AuthLevel = 0
+	AuthLevelNone            AuthLevel = 1
+	AuthLevelConnect         AuthLevel = 2
+	AuthLevelCall            AuthLevel = 3
+	AuthLevelPacket          AuthLevel = 4
+	AuthLevelPacketIntegrity AuthLevel = 5
+	AuthLevelPacketPrivacy   AuthLevel = 6
+)
+
+// ImpLevel is the impersonation level servers are granted on calls
+// (RPC_C_IMP_LEVEL).
+type ImpLevel uint32
+
+const (
+	ImpLevelDefault     ImpLevel = 0
+	ImpLevelAnonymous   ImpLevel = 1
+	ImpLevelIdentify    ImpLevel = 2
+	ImpLevelImpersonate ImpLevel = 3
+	ImpLevelDelegate    ImpLevel = 4
+)
+
+// rpcETooLate is returned by CoInitializeSecurity once security has been
+// set, explicitly or implicitly by the first marshaled interface.
+const rpcETooLate = 0x80010119
+
+type securityLevels struct {
+	auth AuthLevel
+	imp  ImpLevel
+}
+
+// security records whether the process-wide security has been settled.
+var security struct {
+	sync.Mutex
+	done bool
+}
+
+// WithSecurity sets the process-wide COM security with CoInitializeSecurity,
+// as DCOM and remote automation often require, once COM is initialized by
+// the first Do or Go. Security can only be set once per process, before the
+// first COM call that marshals an interface, so configure the first Runner
+// of the program with it. If security is already set, by an earlier Runner
+// or by the host process, WithSecurity has no effect.
+func (r *Runner) WithSecurity(auth AuthLevel, imp ImpLevel) *Runner {
+	r.security = &securityLevels{auth: auth, imp: imp}
+	return r
+}
+
+// apply calls CoInitializeSecurity unless security is already set. COM must
+// be initialized on the calling thread.
+func (s *securityLevels) apply() error {
+	security.Lock()
+	defer security.Unlock()
+	if security.done {
+		return nil
+	}
+	const eoacNone = 0
+	allServices := int32(-1)
+	hr, _, _ := procCoInitializeSecurity.Call(
+		0,                    // pSecDesc
+		uintptr(allServices), // cAuthSvc: let COM choose
+		0,                    // asAuthSvc
+		0,                    // pReserved1
+		uintptr(s.auth),
+		uintptr(s.imp),
+		0, // pAuthList
+		eoacNone,
+		0) // pReserved3
+	if hr != 0 && uint32(hr) != rpcETooLate {
+		return ole.NewError(hr)
+	}
+	security.done = true
+	return nil
+}
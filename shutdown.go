@@ -0,0 +1,92 @@
+//go:build windows
+
+package sugar
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// Shutdown relays process termination signals to the thread that owns a
+// Context, so that cleanup and release happen on the Context's STA thread.
+type Shutdown struct {
+	ctx     Context
+	signals <-chan os.Signal
+	stop    func()
+
+	mu      sync.Mutex
+	defers  []func()
+	stopped sync.Once
+}
+
+// OnShutdown watches for termination signals on behalf of ctx. If signals is
+// nil, os.Interrupt and syscall.SIGTERM are watched via signal.Notify.
+//
+// COM objects must be released on the thread that created them, so the
+// signal is never handled on the signal goroutine. Instead, the thread that
+// owns ctx calls Wait (blocking) or Check (polling, e.g. between units of
+// work), which run the functions registered with Defer and then release ctx.
+func OnShutdown(ctx Context, signals <-chan os.Signal) *Shutdown {
+	s := &Shutdown{ctx: ctx, signals: signals, stop: func() {}}
+	if signals == nil {
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+		s.signals = ch
+		s.stop = func() { signal.Stop(ch) }
+	}
+	return s
+}
+
+// Defer registers fn (typically a Quit call) to run on shutdown before the
+// Context is released. Functions run in LIFO order.
+func (s *Shutdown) Defer(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defers = append(s.defers, fn)
+}
+
+// Wait blocks until a signal arrives or the Context is done, then performs the
+// shutdown on the calling thread. It returns the received signal, or nil if
+// the Context ended first.
+func (s *Shutdown) Wait() os.Signal {
+	select {
+	case sig := <-s.signals:
+		s.run()
+		return sig
+	case <-s.ctx.Done():
+		s.run()
+		return nil
+	}
+}
+
+// Check performs the shutdown on the calling thread and returns true if a
+// signal has been received. It never blocks.
+func (s *Shutdown) Check() bool {
+	select {
+	case <-s.signals:
+		s.run()
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop stops watching for signals. It does not release the Context.
+func (s *Shutdown) Stop() {
+	s.stopped.Do(s.stop)
+}
+
+func (s *Shutdown) run() {
+	s.Stop()
+	s.mu.Lock()
+	defers := s.defers
+	s.defers = nil
+	s.mu.Unlock()
+
+	for i := len(defers) - 1; i >= 0; i-- {
+		defers[i]()
+	}
+	s.ctx.Release()
+}
@@ -3,8 +3,12 @@
 package sugar
 
 import (
+	"context"
 	"errors"
-	"unsafe"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-ole/go-ole"
 	"github.com/go-ole/go-ole/oleutil"
@@ -24,10 +28,92 @@ type Chain interface {
 	// be automatically tracked if a Context is present.
 	Call(method string, params ...interface{}) Chain
 
+	// CallColumns executes a method returning a two-dimensional array and
+	// returns its columns as typed slices (see Columns).
+	CallColumns(method string, params ...interface{}) ([]Column, error)
+
+	// CallSlice executes a method returning a one-dimensional array and
+	// returns its elements. It fails if the result is not an array or has more
+	// than one dimension.
+	CallSlice(method string, params ...interface{}) ([]interface{}, error)
+
+	// CallBool executes a predicate-style method and returns its result as a
+	// bool, accepting VT_BOOL as well as numbers (non-zero is true). For
+	// properties, use Get(prop, params...).GetBool().
+	CallBool(method string, params ...interface{}) (bool, error)
+
+	// Access invokes a member that may be either a parameterized property or a
+	// method (DISPATCH_METHOD|DISPATCH_PROPERTYGET), like VBA does for
+	// expressions such as Cells(1, 1). It returns a NEW Chain.
+	Access(member string, params ...interface{}) Chain
+
+	// Coalesce gets each property in turn and returns a NEW Chain for the first
+	// one that succeeds with a non-nil value, which helps with members that were
+	// renamed across versions of a server. If none does, the returned Chain
+	// carries the last error, or the last nil result if every property succeeded.
+	Coalesce(props ...string) Chain
+
+	// Index returns the item of a collection at the given 1-based position or
+	// string key, using the Item member when present and the default member
+	// otherwise. It returns a NEW Chain.
+	Index(i interface{}) Chain
+
+	// Len returns the number of items in a COM collection, read from its Count
+	// property or, if that is absent, its Length property.
+	Len() (int, error)
+
+	// DefaultValue reads the object's default member (DISPID_VALUE), which VBA
+	// uses implicitly, such as Value for an Excel Range. Like Value, it returns
+	// an error if the result is a COM object.
+	DefaultValue() (interface{}, error)
+
+	// SetDefaultValue sets the object's default member (DISPID_VALUE). It
+	// returns the same Chain instance (or an error-carrying Chain).
+	SetDefaultValue(v interface{}) Chain
+
 	// Put sets a property on the current COM object. It returns the same Chain
 	// instance (or an error-carrying Chain) to allow further operations.
+	// A Currency value is sent as VT_CY and a *big.Rat as VT_DECIMAL.
 	Put(prop string, params ...interface{}) Chain
 
+	// PutRef assigns an object to an object-valued property, like VBA's
+	// Set obj.Prop = other, using DISPATCH_PROPERTYPUTREF. obj may be a Chain
+	// or an *ole.IDispatch; params are the property's index parameters, if
+	// any. Properties that hold a reference rather than a copy need this,
+	// such as ADODB's Recordset.ActiveConnection and Excel's
+	// QueryTable.Recordset and PivotCache.Recordset, where Put fails or
+	// assigns the object's default value instead.
+	PutRef(prop string, obj interface{}, params ...interface{}) Chain
+
+	// CallNamed calls a method passing arguments by parameter name, so that
+	// only the optional parameters of interest need to be given:
+	//
+	//	wbs.CallNamed("Open", map[string]interface{}{"Filename": path, "ReadOnly": true})
+	//
+	// Names are resolved with GetIDsOfNames and are case insensitive.
+	CallNamed(method string, named map[string]interface{}) Chain
+
+	// CallOut calls a method with [out] or [in, out] parameters, passed as
+	// pointers, and returns the values the server left in them, one per
+	// pointer argument in order, along with the chain of the call's result.
+	// The values are also written back through the pointers:
+	//
+	//	var name string
+	//	var ok bool
+	//	outs, res := obj.CallOut("TryGetName", 1, &name, &ok)
+	//
+	// Supported pointers are *string, *bool, *int and *int32 (sent as 32-bit
+	// integers), *int16, *float32, *float64 and *time.Time, which are passed
+	// as typed references such as VT_BYREF|VT_BSTR, and *interface{}, which
+	// is passed as VT_BYREF|VT_VARIANT for VARIANT* parameters and can
+	// receive any value. An object received through *interface{} is a Chain
+	// tracked by the Context.
+	CallOut(method string, params ...interface{}) ([]interface{}, Chain)
+
+	// PutNamed sets a property like Put, passing the parameters of a
+	// parameterized property by name.
+	PutNamed(prop string, value interface{}, named map[string]interface{}) Chain
+
 	// ForEach iterates over a COM collection (any object that implements IEnumVARIANT).
 	// For each item, the callback is executed with a new Chain instance.
 	//
@@ -40,27 +126,169 @@ type Chain interface {
 	// by the caller via Err() if they need to distinguish it from other errors.
 	ForEach(callback func(item Chain) error) Chain
 
+	// ForEachSnapshot iterates like ForEach over a snapshot of the collection
+	// taken up front, so the callback may safely add or delete items.
+	ForEachSnapshot(callback func(item Chain) error) Chain
+
+	// All returns an iterator over the items of a COM collection, for use with
+	// range-over-func (Go 1.23 and later) or called directly with a yield
+	// function on older versions; the result is an iter.Seq[Chain]. Items are
+	// handled as in ForEach. Breaking out of the loop stops the enumeration
+	// without fetching further items. If the collection cannot be enumerated,
+	// a single chain carrying the error is yielded.
+	All() func(yield func(item Chain) bool)
+
+	// All2 is like All but also yields the 0-based position of each item; the
+	// result is an iter.Seq2[int, Chain].
+	All2() func(yield func(i int, item Chain) bool)
+
+	// ToSlice materializes every item of a COM collection into a slice. Each item
+	// holds its own reference until it is released, so prefer ForEach for large
+	// collections.
+	ToSlice() ([]Chain, error)
+
+	// Map applies fn to each item of a COM collection and collects the returned
+	// values. Iteration stops at the first error, which is returned.
+	Map(fn func(item Chain) (interface{}, error)) ([]interface{}, error)
+
+	// Filter returns the items of a COM collection for which pred returns true.
+	// Items that do not match are released immediately.
+	Filter(pred func(item Chain) (bool, error)) ([]Chain, error)
+
+	// Find returns the first item of a COM collection for which pred returns
+	// true, or ErrNotFound. Items that do not match are released immediately.
+	Find(pred func(item Chain) (bool, error)) (Chain, error)
+
+	// ForEachUntil iterates over a COM collection like ForEach, stopping without
+	// recording an error as soon as the callback returns false.
+	ForEachUntil(callback func(item Chain) bool) Chain
+
+	// Observe polls a property on the given interval and emits its value on the
+	// returned channel whenever it changes. Polls run while the owning thread
+	// blocks in Wait. Call the returned function to stop; it returns the
+	// error that ended the observation, if any.
+	Observe(prop string, interval time.Duration) (<-chan interface{}, func() error)
+
+	// On subscribes handler to the named event of the object through its
+	// connection points, and returns a function that unsubscribes. The handler
+	// receives the event arguments in declaration order: COM objects as Chains
+	// that are only valid during the call (Fork them to keep them), and
+	// by-reference booleans, such as Cancel, as a *bool it may set.
+	//
+	// Events are delivered on the thread that subscribed, while it is making a
+	// COM call or waiting in Wait under a Runner with WithMessagePump. The
+	// Context and the object must outlive the subscription, and unsubscribing
+	// must happen on the same thread.
+	On(event string, handler func(args []interface{}) error) (func(), error)
+
+	// CheckThread returns an error wrapping ErrWrongThread, with advice on
+	// marshaling, if the current OS thread is not the one the chain was created
+	// on. Chains created in the MTA are not bound to a thread, so it returns
+	// nil for them. See also the CheckThreads option.
+	CheckThread() error
+
 	// Fork creates a new independent reference to the current COM object.
 	// Both the original and the forked Chain will point to the same object
 	// but are managed as separate entries in the Context's arena.
 	Fork() Chain
 
+	// QueryInterface asks the object for another interface, which must derive
+	// from IDispatch, and returns a new Chain holding it. The new Chain is
+	// tracked by the Context like any other.
+	QueryInterface(iid *ole.GUID) (Chain, error)
+
+	// Dump reads the object's scalar properties, as listed by its type
+	// information, into a map keyed by property name. It is meant for
+	// debugging: the map is partial when some properties fail, and the
+	// error joins the failures.
+	Dump() (map[string]interface{}, error)
+
+	// Members lists the object's methods and properties from its type
+	// information, which can be used to validate names before calling them.
+	Members() ([]MemberInfo, error)
+
 	// Store increases the reference count and returns the raw *ole.IDispatch.
 	// The caller is responsible for calling Release() on the returned object
 	// if it's not managed by sugar.Context.
 	Store() (*ole.IDispatch, error)
 
+	// AutoRelease flags the chain so that the held COM object and the last result
+	// are released by a finalizer once the chain becomes unreachable. Chains
+	// derived from it inherit the flag. It is a no-op for chains tracked by a
+	// Context, which already owns their lifetime.
+	//
+	// Finalizers run on the runtime's finalizer goroutine, not on the thread that
+	// created the object, and in no particular order. This is tolerated by
+	// out-of-process servers such as Excel, but it violates STA thread affinity,
+	// so prefer a Context (or an explicit Release) for in-process objects.
+	AutoRelease() Chain
+
+	// ReleaseAfter releases the chain once ctx is done, tying the lifetime of
+	// the COM object to a deadline or cancellation instead of the arena.
+	//
+	// To respect thread affinity, a chain tracked by a Context is not released
+	// on the watching goroutine: it is queued and released, and untracked, by
+	// the next COM call made through that Context (or by its Release). An
+	// untracked chain is released on the watching goroutine, with the same
+	// caveats as AutoRelease. The watcher lives until ctx is done.
+	ReleaseAfter(ctx context.Context) Chain
+
 	// Release manually releases the held COM object. Usually, this is handled
 	// automatically by the sugar.Context, but can be used for early cleanup.
+	// Only the first call has an effect, so a chain released early is safely
+	// skipped when its Context is released.
 	Release() error
 
 	// IsDispatch returns true if the last operation's result is a COM object (IDispatch).
 	IsDispatch() bool
 
+	// IsNothing returns true if the last operation's result is a null object
+	// reference, VBA's Nothing, such as Excel's Range.Find when nothing
+	// matches.
+	IsNothing() bool
+
+	// IsNil returns true if the last operation's result is VT_NULL, such as
+	// Excel's Font.Bold on a range with mixed formatting.
+	IsNil() bool
+
+	// IsEmpty returns true if the last operation's result is VT_EMPTY, such as
+	// the value of a blank cell. It is false when there is no result yet.
+	IsEmpty() bool
+
+	// CellError returns the last result as a *CellError if it is VT_ERROR,
+	// such as #N/A from an Excel function, whether or not the Context was
+	// created with ErrorsAsErrors.
+	CellError() (*CellError, bool)
+
 	// Value retrieves the underlying Go value of the last operation's result.
 	// Returns an error if the result is a COM object (use Store() instead).
+	// VT_DATE results are returned as a time.Time in UTC carrying the stored
+	// wall clock, VT_CY results as a Currency and VT_DECIMAL results as an
+	// exact *big.Rat.
 	Value() (interface{}, error)
 
+	// Values2D reads the Value property of a range-like object in a single COM
+	// call and returns it as rows of Go values.
+	Values2D(opts ...ValuesOption) ([][]interface{}, error)
+
+	// SetValues2D writes a rectangular grid to the Value property of a
+	// range-like object in a single COM call.
+	SetValues2D(data [][]interface{}) Chain
+
+	// GetString returns the last result as a string.
+	GetString() (string, error)
+
+	// GetInt returns the last result as an int64. Integral floating-point values
+	// are accepted since many servers report every number as a double.
+	GetInt() (int64, error)
+
+	// GetBool returns the last result as a bool. Numbers are treated as true
+	// when non-zero.
+	GetBool() (bool, error)
+
+	// GetFloat returns the last result as a float64.
+	GetFloat() (float64, error)
+
 	// Err returns the first error encountered in the chain of operations.
 	Err() error
 }
@@ -70,6 +298,23 @@ type chain struct {
 	err        error
 	lastResult *ole.VARIANT
 	ctx        Context
+
+	// borrowed is set when disp is shared with the parent chain without an
+	// extra reference, in which case Release must not release it.
+	borrowed bool
+	// parent is the chain a borrowed chain shares disp with. Holding it keeps
+	// an AutoRelease finalizer from releasing disp while this chain is in use.
+	parent      *chain
+	autoRelease bool
+	// thread is the OS thread the object was obtained on, or 0 if unknown.
+	thread uint32
+	// root is set by Context.Track for chains that were not derived from
+	// another chain of a Context, such as those from Create or From.
+	root bool
+	// released guards against releasing the object twice, for example when
+	// a chain is released by hand and again by its Context, or by a
+	// ReleaseAfter watcher.
+	released atomic.Bool
 }
 
 // From starts a new chain with the given IDispatch.
@@ -78,7 +323,8 @@ func From(disp *ole.IDispatch) Chain {
 		disp.AddRef()
 	}
 	return &chain{
-		disp: disp,
+		disp:   disp,
+		thread: ownerThread(),
 	}
 }
 
@@ -96,7 +342,8 @@ func Create(progID string) Chain {
 	}
 
 	return &chain{
-		disp: disp,
+		disp:   disp,
+		thread: ownerThread(),
 	}
 }
 
@@ -114,7 +361,8 @@ func GetActive(progID string) Chain {
 	}
 
 	return &chain{
-		disp: disp,
+		disp:   disp,
+		thread: ownerThread(),
 	}
 }
 
@@ -123,25 +371,36 @@ func (c *chain) handleResult(result *ole.VARIANT, err error) Chain {
 		return &chain{err: err, ctx: c.ctx}
 	}
 
-	newChain := &chain{
-		disp:       c.disp,
-		lastResult: result,
-		ctx:        c.ctx,
-	}
+	newChain := c.borrow(nil)
+	newChain.lastResult = result
 
 	if result.VT == ole.VT_DISPATCH {
+		// A null dispatch (VBA's Nothing) leaves the chain without an object.
 		newDisp := result.ToIDispatch()
-		newDisp.AddRef()
+		if newDisp != nil {
+			newDisp.AddRef()
+		}
 		newChain.disp = newDisp
+		newChain.borrowed = false
+		newChain.parent = nil
 		
 		if c.ctx != nil {
 			c.ctx.Track(newChain)
 		}
 	}
 
+	if c.autoRelease {
+		newChain.AutoRelease()
+	}
 	return newChain
 }
 
+// borrow returns a chain sharing c's object without an extra reference,
+// carrying err if it is not nil.
+func (c *chain) borrow(err error) *chain {
+	return &chain{err: err, ctx: c.ctx, disp: c.disp, borrowed: true, parent: c, thread: c.thread}
+}
+
 // Get retrieves a property and returns a NEW Chain.
 func (c *chain) Get(prop string, params ...interface{}) Chain {
 	if c.err != nil {
@@ -150,7 +409,7 @@ func (c *chain) Get(prop string, params ...interface{}) Chain {
 	if c.disp == nil {
 		return &chain{err: errors.New("dispatch is nil"), ctx: c.ctx}
 	}
-	result, err := oleutil.GetProperty(c.disp, prop, params...)
+	result, err := c.invoke(OpGet, prop, ole.DISPATCH_PROPERTYGET, params)
 	return c.handleResult(result, err)
 }
 
@@ -162,19 +421,159 @@ func (c *chain) Call(method string, params ...interface{}) Chain {
 	if c.disp == nil {
 		return &chain{err: errors.New("dispatch is nil"), ctx: c.ctx}
 	}
-	result, err := oleutil.CallMethod(c.disp, method, params...)
+	result, err := c.invoke(OpCall, method, ole.DISPATCH_METHOD, params)
 	return c.handleResult(result, err)
 }
 
+// CallBool executes a method and coerces its result to a bool.
+func (c *chain) CallBool(method string, params ...interface{}) (bool, error) {
+	return c.Call(method, params...).GetBool()
+}
+
+// Access invokes a property-or-method member and returns a NEW Chain.
+func (c *chain) Access(member string, params ...interface{}) Chain {
+	if c.err != nil {
+		return &chain{err: c.err, ctx: c.ctx}
+	}
+	if c.disp == nil {
+		return &chain{err: errors.New("dispatch is nil"), ctx: c.ctx}
+	}
+	result, err := c.invoke(OpAccess, member, ole.DISPATCH_METHOD|ole.DISPATCH_PROPERTYGET, params)
+	return c.handleResult(result, err)
+}
+
+// Coalesce returns a NEW Chain for the first property yielding a non-nil value.
+func (c *chain) Coalesce(props ...string) Chain {
+	if c.err != nil {
+		return &chain{err: c.err, ctx: c.ctx}
+	}
+	var last Chain = &chain{err: errors.New("no properties to coalesce"), ctx: c.ctx}
+	var lastErr Chain
+	for _, prop := range props {
+		last = c.Get(prop)
+		if last.Err() != nil {
+			lastErr = last
+			continue
+		}
+		if !last.(*chain).isNil() {
+			return last
+		}
+	}
+	if lastErr != nil {
+		return lastErr
+	}
+	return last
+}
+
+// isNil reports whether the last result is empty, null or Nothing.
+func (c *chain) isNil() bool {
+	if c.lastResult == nil {
+		return true
+	}
+	switch c.lastResult.VT {
+	case ole.VT_EMPTY, ole.VT_NULL:
+		return true
+	case ole.VT_DISPATCH:
+		return c.disp == nil
+	}
+	return false
+}
+
+// Index retrieves a collection item by position or key and returns a NEW Chain.
+func (c *chain) Index(i interface{}) Chain {
+	if c.err != nil {
+		return &chain{err: c.err, ctx: c.ctx}
+	}
+	if c.disp == nil {
+		return &chain{err: errors.New("dispatch is nil"), ctx: c.ctx}
+	}
+	if err := c.preInvoke(OpGet, "Item"); err != nil {
+		return &chain{err: err, ctx: c.ctx}
+	}
+	const flags = ole.DISPATCH_METHOD | ole.DISPATCH_PROPERTYGET
+	if dispid, err := c.dispID("Item"); err == nil {
+		result, err := c.invokeID(OpGet, "Item", dispid, flags, []interface{}{i})
+		return c.handleResult(result, err)
+	}
+	result, err := c.invokeID(OpGet, "", ole.DISPID_VALUE, flags, []interface{}{i})
+	if err != nil {
+		return &chain{err: fmt.Errorf("object is not an indexable collection: %w", err), ctx: c.ctx}
+	}
+	return c.handleResult(result, nil)
+}
+
+// DefaultValue reads the default member (DISPID_VALUE) of the object.
+func (c *chain) DefaultValue() (interface{}, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.disp == nil {
+		return nil, errors.New("dispatch is nil")
+	}
+	if err := c.preInvoke(OpGet, ""); err != nil {
+		return nil, err
+	}
+	result, err := c.invokeID(OpGet, "", ole.DISPID_VALUE, ole.DISPATCH_PROPERTYGET, nil)
+	return c.handleResult(result, err).Value()
+}
+
+// SetDefaultValue sets the default member (DISPID_VALUE) of the object.
+func (c *chain) SetDefaultValue(v interface{}) Chain {
+	if c.err != nil || c.disp == nil {
+		return c
+	}
+	if err := c.preInvoke(OpPut, ""); err != nil {
+		return c.borrow(err)
+	}
+	_, err := c.invokeID(OpPut, "", ole.DISPID_VALUE, ole.DISPATCH_PROPERTYPUT, []interface{}{v})
+	if err != nil {
+		return c.borrow(err)
+	}
+	return c
+}
+
+// Len returns the number of items in a collection.
+func (c *chain) Len() (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	if c.disp == nil {
+		return 0, errors.New("dispatch is nil")
+	}
+	for _, prop := range []string{"Count", "Length"} {
+		if _, err := c.dispID(prop); err != nil {
+			continue
+		}
+		n, err := c.Get(prop).GetInt()
+		return int(n), err
+	}
+	return 0, errors.New("object has neither Count nor Length")
+}
+
+// PutRef assigns an object to a property by reference and returns the chain.
+func (c *chain) PutRef(prop string, obj interface{}, params ...interface{}) Chain {
+	if c.err != nil || c.disp == nil {
+		return c
+	}
+
+	// The value comes after the index parameters.
+	args := append(append([]interface{}(nil), params...), obj)
+	_, err := c.invoke(OpPutRef, prop, ole.DISPATCH_PROPERTYPUTREF, args)
+	if err != nil {
+		return c.borrow(err)
+	}
+	return c
+}
+
 // Put sets a property and returns the chain.
 func (c *chain) Put(prop string, params ...interface{}) Chain {
 	if c.err != nil || c.disp == nil {
 		return c
 	}
 
-	_, err := oleutil.PutProperty(c.disp, prop, params...)
+	_, err := c.invoke(OpPut, prop, ole.DISPATCH_PROPERTYPUT, params)
 	if err != nil {
-		return &chain{err: err, ctx: c.ctx, disp: c.disp}
+		return c.borrow(err)
 	}
 	
 	return c
@@ -207,62 +606,60 @@ func (c *chain) ForEach(callback func(item Chain) error) Chain {
 		return c
 	}
 
-	enumVar, err := oleutil.GetProperty(c.disp, "_NewEnum")
+	err := c.enumerate(func(itemVar *ole.VARIANT) error {
+		if itemVar.VT != ole.VT_DISPATCH {
+			return nil
+		}
+		itemChain := c.newItem(itemVar)
+
+		cbErr := callback(itemChain)
+
+		if c.ctx == nil {
+			itemChain.Release()
+		}
+		return cbErr
+	})
 	if err != nil {
 		return &chain{err: err, ctx: c.ctx}
 	}
-	defer enumVar.Clear()
+	return c
+}
 
-	if enumVar.VT != ole.VT_UNKNOWN && enumVar.VT != ole.VT_DISPATCH {
-		return &chain{err: errors.New("_NewEnum is not object"), ctx: c.ctx}
+// ForEachUntil executes a callback for each item until it returns false.
+func (c *chain) ForEachUntil(callback func(item Chain) bool) Chain {
+	res := c.ForEach(func(item Chain) error {
+		if !callback(item) {
+			return ErrForEachBreak
+		}
+		return nil
+	})
+	if errors.Is(res.Err(), ErrForEachBreak) {
+		return c
 	}
+	return res
+}
 
-	unknown := enumVar.ToIUnknown()
-	if unknown == nil {
-		return &chain{err: errors.New("_NewEnum nil"), ctx: c.ctx}
+// QueryInterface returns a chain on another IDispatch-derived interface of
+// the object.
+func (c *chain) QueryInterface(iid *ole.GUID) (Chain, error) {
+	if c.err != nil {
+		return nil, c.err
 	}
-
-	iid, _ := ole.IIDFromString("{00020404-0000-0000-C000-000000000046}")
-	enumRaw, err := unknown.QueryInterface(iid)
+	if c.disp == nil {
+		return nil, errors.New("nil dispatch")
+	}
+	disp, err := c.disp.QueryInterface(iid)
 	if err != nil {
-		return &chain{err: err, ctx: c.ctx}
+		return nil, fmt.Errorf("QueryInterface %s: %w", iid, err)
 	}
-	defer enumRaw.Release()
-
-	enum := (*ole.IEnumVARIANT)(unsafe.Pointer(enumRaw))
-
-	for {
-		itemVar, fetched, err := enum.Next(1)
-		if err != nil || fetched == 0 {
-			break
-		}
-
-		if itemVar.VT == ole.VT_DISPATCH {
-			itemDisp := itemVar.ToIDispatch()
-			itemDisp.AddRef()
-
-			itemChain := &chain{
-				disp: itemDisp,
-				ctx:  c.ctx,
-			}
-			if c.ctx != nil {
-				c.ctx.Track(itemChain)
-			}
-
-			cbErr := callback(itemChain)
-
-			if c.ctx == nil {
-				itemChain.Release()
-			}
-
-			if cbErr != nil {
-				itemVar.Clear()
-				return &chain{err: cbErr, ctx: c.ctx}
-			}
-		}
-		itemVar.Clear()
+	newChain := &chain{disp: disp, ctx: c.ctx, thread: c.thread}
+	if c.ctx != nil {
+		c.ctx.Track(newChain)
 	}
-	return c
+	if c.autoRelease {
+		newChain.AutoRelease()
+	}
+	return newChain, nil
 }
 
 // Fork creates a new independent reference to the current object.
@@ -274,10 +671,13 @@ func (c *chain) Fork() Chain {
 		return &chain{err: errors.New("nil dispatch"), ctx: c.ctx}
 	}
 	c.disp.AddRef()
-	newChain := &chain{disp: c.disp, ctx: c.ctx}
+	newChain := &chain{disp: c.disp, ctx: c.ctx, thread: c.thread}
 	if c.ctx != nil {
 		c.ctx.Track(newChain)
 	}
+	if c.autoRelease {
+		newChain.AutoRelease()
+	}
 	return newChain
 }
 
@@ -294,10 +694,19 @@ func (c *chain) Store() (*ole.IDispatch, error) {
 	return c.disp, nil
 }
 
-// Release releases the held dispatch object and captures errors.
+// Release releases the held dispatch object and captures errors. Releasing a
+// chain again is a no-op.
 func (c *chain) Release() error {
+	if !c.released.CompareAndSwap(false, true) {
+		return nil
+	}
 	if c.disp != nil {
-		c.disp.Release()
+		if !c.borrowed {
+			if cache := c.options().dispids; cache != nil {
+				cache.forget(c.disp)
+			}
+			c.disp.Release()
+		}
 		c.disp = nil
 	}
 	if c.lastResult != nil {
@@ -309,11 +718,63 @@ func (c *chain) Release() error {
 	return err
 }
 
+// AutoRelease schedules the chain to be released when it becomes unreachable.
+func (c *chain) AutoRelease() Chain {
+	if c.ctx != nil || c.autoRelease {
+		return c
+	}
+	c.autoRelease = true
+	runtime.SetFinalizer(c, func(c *chain) {
+		c.Release()
+	})
+	return c
+}
+
+// ReleaseAfter schedules the chain to be released when ctx is done.
+func (c *chain) ReleaseAfter(ctx context.Context) Chain {
+	if c.err != nil {
+		return c
+	}
+	sc, _ := c.ctx.(*sugarContext)
+	go func() {
+		<-ctx.Done()
+		if sc != nil {
+			sc.releaseLater(c)
+		} else {
+			c.Release()
+		}
+	}()
+	return c
+}
+
 // IsDispatch returns true if the last result is a dispatch object.
 func (c *chain) IsDispatch() bool {
 	return c.lastResult != nil && c.lastResult.VT == ole.VT_DISPATCH
 }
 
+// IsNothing returns true if the last result is a null dispatch.
+func (c *chain) IsNothing() bool {
+	return c.IsDispatch() && c.disp == nil
+}
+
+// IsNil returns true if the last result is VT_NULL.
+func (c *chain) IsNil() bool {
+	return c.lastResult != nil && c.lastResult.VT == ole.VT_NULL
+}
+
+// IsEmpty returns true if the last result is VT_EMPTY.
+func (c *chain) IsEmpty() bool {
+	return c.lastResult != nil && c.lastResult.VT == ole.VT_EMPTY
+}
+
+// CellError returns the last result as a *CellError if it is VT_ERROR.
+func (c *chain) CellError() (*CellError, bool) {
+	if c.lastResult == nil || c.lastResult.VT != ole.VT_ERROR {
+		return nil, false
+	}
+	return &CellError{Code: uint32(c.lastResult.Val)}, true
+}
+
 // Value retrieves the Go value of the last operation result.
 func (c *chain) Value() (interface{}, error) {
 	if c.err != nil {
@@ -325,7 +786,54 @@ func (c *chain) Value() (interface{}, error) {
 	if c.lastResult.VT == ole.VT_DISPATCH {
 		return nil, errors.New("result is IDispatch, use Store")
 	}
-	return c.lastResult.Value(), nil
+	if c.lastResult.VT == ole.VT_ERROR && c.options().errorsAsErrors {
+		return nil, &CellError{Code: uint32(c.lastResult.Val)}
+	}
+	return variantValue(c.lastResult), nil
+}
+
+// GetString returns the last result as a string.
+func (c *chain) GetString() (string, error) {
+	v, err := c.Value()
+	if err != nil {
+		return "", err
+	}
+	return toString(v)
+}
+
+// GetInt returns the last result as an int64.
+func (c *chain) GetInt() (int64, error) {
+	v, err := c.Value()
+	if err != nil {
+		return 0, err
+	}
+	return toInt64(v)
+}
+
+// GetBool returns the last result as a bool.
+func (c *chain) GetBool() (bool, error) {
+	v, err := c.Value()
+	if err != nil {
+		return false, err
+	}
+	return toBool(v)
+}
+
+// GetFloat returns the last result as a float64.
+func (c *chain) GetFloat() (float64, error) {
+	v, err := c.Value()
+	if err != nil {
+		return 0, err
+	}
+	return toFloat64(v)
+}
+
+// options returns the options of the owning Context, or the defaults.
+func (c *chain) options() contextOptions {
+	if sc, ok := c.ctx.(*sugarContext); ok {
+		return sc.opts
+	}
+	return contextOptions{}
 }
 
 // Err returns the first error encountered in the chain.
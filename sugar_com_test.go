@@ -3,8 +3,12 @@
 package sugar_test
 
 import (
+	"context"
 	"errors"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/xll-gen/sugar"
 )
@@ -126,6 +130,18 @@ func TestChain_Errors(t *testing.T) {
 		if err == nil {
 			t.Error("expected error for non-existent property, got nil")
 		}
+
+		var comErr *sugar.ComError
+		if !errors.As(err, &comErr) {
+			t.Fatalf("expected *ComError, got %T", err)
+		}
+		if comErr.Op != sugar.OpGet || comErr.Member != "NonExistentProperty" {
+			t.Errorf("unexpected operation %s %s", comErr.Op, comErr.Member)
+		}
+		// DISP_E_UNKNOWNNAME
+		if code, ok := sugar.HRESULT(err); !ok || code != 0x80020006 {
+			t.Errorf("expected HRESULT 0x80020006, got 0x%08X (%v)", code, ok)
+		}
 		return nil
 	})
 }
@@ -224,6 +240,19 @@ func TestChain_ForEach(t *testing.T) {
 		if err == nil || err.Error() != "custom error" {
 			t.Errorf("expected custom error, got %v", err)
 		}
+
+		count = 0
+		err = wbs.ForEachUntil(func(item sugar.Chain) bool {
+			count++
+			return false
+		}).Err()
+
+		if err != nil {
+			t.Errorf("expected no error from ForEachUntil, got %v", err)
+		}
+		if count != 1 {
+			t.Errorf("expected count 1 with ForEachUntil, got %d", count)
+		}
 		return nil
 	})
 }
@@ -245,4 +274,1028 @@ func TestChain_Fork(t *testing.T) {
 		}
 		return nil
 	})
-}
\ No newline at end of file
+}
+func TestChain_ErrorsAsErrors(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		cell := excel.Get("Workbooks").Call("Add").Get("ActiveSheet").Get("Range", "A1")
+		if err := cell.Put("Formula", "=NA()").Err(); err != nil {
+			t.Fatalf("failed to set formula: %v", err)
+		}
+
+		if _, err := cell.Get("Value").Value(); err != nil {
+			t.Errorf("expected no error without ErrorsAsErrors, got %v", err)
+		}
+
+		cellDisp, err := cell.Store()
+		if err != nil {
+			t.Fatalf("failed to store cell: %v", err)
+		}
+		defer cellDisp.Release()
+
+		optCtx := sugar.NewContext(ctx, sugar.ErrorsAsErrors())
+		defer optCtx.Release()
+
+		_, err = optCtx.From(cellDisp).Get("Value").Value()
+		var cellErr *sugar.CellError
+		if !errors.As(err, &cellErr) {
+			t.Fatalf("expected *CellError, got %v", err)
+		}
+		if cellErr.Code&0xFFFF != 2042 {
+			t.Errorf("expected #N/A (2042), got 0x%08X", cellErr.Code)
+		}
+		return nil
+	})
+}
+
+func TestChain_TypedAccessors(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := excel.Get("Workbooks").Call("Add").Get("ActiveSheet")
+		sheet.Get("Range", "A1").Put("Value", "Sugar")
+		sheet.Get("Range", "A2").Put("Value", 42)
+		sheet.Get("Range", "A3").Put("Value", 1.5)
+
+		if s, err := sheet.Get("Range", "A1").Get("Value").GetString(); err != nil || s != "Sugar" {
+			t.Errorf("GetString: expected 'Sugar', got %q (%v)", s, err)
+		}
+		if n, err := sheet.Get("Range", "A2").Get("Value").GetInt(); err != nil || n != 42 {
+			t.Errorf("GetInt: expected 42, got %d (%v)", n, err)
+		}
+		if f, err := sheet.Get("Range", "A3").Get("Value").GetFloat(); err != nil || f != 1.5 {
+			t.Errorf("GetFloat: expected 1.5, got %v (%v)", f, err)
+		}
+		if b, err := excel.Get("Visible").GetBool(); err != nil || b {
+			t.Errorf("GetBool: expected false, got %v (%v)", b, err)
+		}
+		if n, err := excel.Get("Workbooks").Get("Count").GetInt(); err != nil || n < 1 {
+			t.Errorf("GetInt: expected at least 1 workbook, got %d (%v)", n, err)
+		}
+
+		if _, err := sheet.Get("Range", "A1").Get("Value").GetInt(); err == nil {
+			t.Error("expected error for GetInt on a string")
+		}
+		if _, err := sheet.Get("Range", "A3").Get("Value").GetInt(); err == nil {
+			t.Error("expected error for GetInt on a fractional number")
+		}
+		if _, err := sheet.GetString(); err == nil {
+			t.Error("expected error for GetString on an IDispatch result")
+		}
+		if _, err := excel.Get("NonExistentProperty").GetString(); err == nil {
+			t.Error("expected chained error to short-circuit GetString")
+		}
+		return nil
+	})
+}
+
+func TestAsResult(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		excel.Get("Workbooks").Call("Add")
+		count, err := sugar.AsResult[int](excel.Get("Workbooks"), "Count").Unwrap()
+		if err != nil || count < 1 {
+			t.Errorf("expected at least 1 workbook, got %d (%v)", count, err)
+		}
+		if name := sugar.AsResult[string](excel, "Name").Must(); name == "" {
+			t.Error("expected non-empty application name")
+		}
+		if v := sugar.AsResult[int](excel, "Name").OrElse(-1); v != -1 {
+			t.Errorf("expected fallback for string-to-int, got %d", v)
+		}
+		return nil
+	})
+}
+
+func TestChain_Index(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		wb := excel.Get("Workbooks").Call("Add")
+		sheets := wb.Get("Worksheets")
+
+		name, err := sheets.Index(1).Get("Name").GetString()
+		if err != nil {
+			t.Fatalf("Index by position failed: %v", err)
+		}
+		if byKey, err := sheets.Index(name).Get("Name").GetString(); err != nil || byKey != name {
+			t.Errorf("Index by key: expected %q, got %q (%v)", name, byKey, err)
+		}
+
+		if err := excel.Index(1).Err(); err == nil {
+			t.Error("expected error when indexing a non-collection")
+		}
+		return nil
+	})
+}
+
+func TestChain_Len(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		wbs := excel.Get("Workbooks")
+		wbs.Call("Add")
+
+		n, err := wbs.Len()
+		if err != nil {
+			t.Fatalf("Len failed: %v", err)
+		}
+		if n < 1 {
+			t.Errorf("expected at least 1 workbook, got %d", n)
+		}
+
+		if _, err := excel.Len(); err == nil {
+			t.Error("expected error for an object without Count or Length")
+		}
+		return nil
+	})
+}
+
+func TestChain_ToSlice(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		wbs := excel.Get("Workbooks")
+		wbs.Call("Add")
+		wbs.Call("Add")
+
+		items, err := wbs.ToSlice()
+		if err != nil {
+			t.Fatalf("ToSlice failed: %v", err)
+		}
+		n, _ := wbs.Len()
+		if len(items) != n {
+			t.Fatalf("expected %d items, got %d", n, len(items))
+		}
+		for i := len(items) - 1; i >= 0; i-- {
+			if _, err := items[i].Get("Name").GetString(); err != nil {
+				t.Errorf("item %d: failed to get Name: %v", i, err)
+			}
+		}
+		return nil
+	})
+}
+
+func TestChain_Map(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		sheets := excel.Get("Workbooks").Call("Add").Get("Worksheets")
+		names, err := sheets.Map(func(item sugar.Chain) (interface{}, error) {
+			return item.Get("Name").GetString()
+		})
+		if err != nil {
+			t.Fatalf("Map failed: %v", err)
+		}
+		n, _ := sheets.Len()
+		if len(names) != n {
+			t.Fatalf("expected %d names, got %d", n, len(names))
+		}
+		for i, name := range names {
+			if s, ok := name.(string); !ok || s == "" {
+				t.Errorf("name %d: expected non-empty string, got %v", i, name)
+			}
+		}
+
+		_, err = sheets.Map(func(item sugar.Chain) (interface{}, error) {
+			return nil, errors.New("stop")
+		})
+		if err == nil || err.Error() != "stop" {
+			t.Errorf("expected callback error, got %v", err)
+		}
+		return nil
+	})
+}
+
+func TestChain_Filter(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		sheets := excel.Get("Workbooks").Call("Add").Get("Worksheets")
+		sheets.Call("Add")
+		first, _ := sheets.Index(1).Get("Name").GetString()
+
+		matched, err := sheets.Filter(func(item sugar.Chain) (bool, error) {
+			name, err := item.Get("Name").GetString()
+			return name == first, err
+		})
+		if err != nil {
+			t.Fatalf("Filter failed: %v", err)
+		}
+		if len(matched) != 1 {
+			t.Fatalf("expected 1 match, got %d", len(matched))
+		}
+		if name, _ := matched[0].Get("Name").GetString(); name != first {
+			t.Errorf("expected %q, got %q", first, name)
+		}
+		return nil
+	})
+}
+
+func TestMap(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		sheets := excel.Get("Workbooks").Call("Add").Get("Worksheets")
+		names, err := sugar.Map(sheets, func(item sugar.Chain) (string, error) {
+			return item.Get("Name").GetString()
+		})
+		if err != nil {
+			t.Fatalf("Map failed: %v", err)
+		}
+		n, _ := sheets.Len()
+		if len(names) != n {
+			t.Fatalf("expected %d names, got %d", n, len(names))
+		}
+		first, _ := sheets.Index(1).Get("Name").GetString()
+		if names[0] != first {
+			t.Errorf("expected first name %q, got %q", first, names[0])
+		}
+		return nil
+	})
+}
+
+func TestChain_Find(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		sheets := excel.Get("Workbooks").Call("Add").Get("Worksheets")
+		sheets.Call("Add")
+		last, _ := sheets.Index(2).Get("Name").GetString()
+
+		visited := 0
+		found, err := sheets.Find(func(item sugar.Chain) (bool, error) {
+			visited++
+			name, err := item.Get("Name").GetString()
+			return name == last, err
+		})
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if name, _ := found.Get("Name").GetString(); name != last {
+			t.Errorf("expected %q, got %q", last, name)
+		}
+		if visited != 2 {
+			t.Errorf("expected enumeration to stop after 2 items, visited %d", visited)
+		}
+
+		found, err = sheets.Find(func(item sugar.Chain) (bool, error) {
+			return false, nil
+		})
+		if !errors.Is(err, sugar.ErrNotFound) || found != nil {
+			t.Errorf("expected (nil, ErrNotFound), got (%v, %v)", found, err)
+		}
+		return nil
+	})
+}
+
+func TestChain_ForEachSnapshot(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		excel.Put("DisplayAlerts", false)
+		defer excel.Call("Quit")
+
+		wbs := excel.Get("Workbooks")
+		for i := 0; i < 3; i++ {
+			wbs.Call("Add")
+		}
+
+		closed := 0
+		err := wbs.ForEachSnapshot(func(item sugar.Chain) error {
+			closed++
+			return item.Call("Close", false).Err()
+		}).Err()
+		if err != nil {
+			t.Fatalf("ForEachSnapshot failed: %v", err)
+		}
+		if closed != 3 {
+			t.Errorf("expected to close 3 workbooks, closed %d", closed)
+		}
+		if n, _ := wbs.Len(); n != 0 {
+			t.Errorf("expected no workbooks left, got %d", n)
+		}
+		return nil
+	})
+}
+
+func TestChain_ChainParams(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := excel.Get("Workbooks").Call("Add").Get("ActiveSheet")
+		topLeft := sheet.Get("Cells", 1, 1)
+		bottomRight := sheet.Get("Cells", 2, 3)
+
+		addr, err := sheet.Get("Range", topLeft, bottomRight).Get("Address").GetString()
+		if err != nil {
+			t.Fatalf("Range with Chain arguments failed: %v", err)
+		}
+		if addr != "$A$1:$C$2" {
+			t.Errorf("expected $A$1:$C$2, got %s", addr)
+		}
+
+		// The argument chains remain usable after the call.
+		if err := topLeft.Put("Value", "still valid").Err(); err != nil {
+			t.Errorf("argument chain unusable after call: %v", err)
+		}
+		return nil
+	})
+}
+
+func TestChain_DateParams(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		cell := excel.Get("Workbooks").Call("Add").Get("ActiveSheet").Get("Range", "A1")
+		want := time.Date(2024, 2, 29, 13, 45, 30, 0, time.UTC)
+		if err := cell.Put("Value", want).Err(); err != nil {
+			t.Fatalf("failed to put date: %v", err)
+		}
+
+		val, err := cell.Get("Value").Value()
+		if err != nil {
+			t.Fatalf("failed to get date: %v", err)
+		}
+		got, ok := val.(time.Time)
+		if !ok {
+			t.Fatalf("expected time.Time, got %T", val)
+		}
+		if !got.Equal(want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+
+		// Value2 exposes the raw OLE Automation date serial.
+		serial, err := cell.Get("Value2").GetFloat()
+		if err != nil || serial < 45351.5732 || serial > 45351.5733 {
+			t.Errorf("expected serial 45351.57326..., got %v (%v)", serial, err)
+		}
+		return nil
+	})
+}
+
+func TestChain_Values2D(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := excel.Get("Workbooks").Call("Add").Get("ActiveSheet")
+		sheet.Get("Range", "A1").Put("Value", "name")
+		sheet.Get("Range", "B1").Put("Value", "qty")
+		sheet.Get("Range", "A2").Put("Value", "apple")
+		sheet.Get("Range", "B2").Put("Value", 3)
+
+		grid, err := sheet.Get("Range", "A1:B2").Values2D()
+		if err != nil {
+			t.Fatalf("Values2D failed: %v", err)
+		}
+		if len(grid) != 2 || len(grid[0]) != 2 {
+			t.Fatalf("expected 2x2 grid, got %v", grid)
+		}
+		if grid[0][0] != "name" || grid[1][0] != "apple" || grid[1][1] != float64(3) {
+			t.Errorf("unexpected grid content: %v", grid)
+		}
+
+		single, err := sheet.Get("Range", "A1").Values2D()
+		if err != nil || len(single) != 1 || len(single[0]) != 1 || single[0][0] != "name" {
+			t.Errorf("expected 1x1 grid for a single cell, got %v (%v)", single, err)
+		}
+
+		// Formatting empty cells makes UsedRange over-report its extent.
+		sheet.Get("Range", "A1:D10").Put("NumberFormat", "0.00")
+		used := sheet.Get("UsedRange")
+
+		full, err := used.Values2D()
+		if err != nil || len(full) != 10 || len(full[0]) != 4 {
+			t.Errorf("expected untrimmed 10x4 grid, got %dx%d (%v)", len(full), len(full[0]), err)
+		}
+		trimmed, err := used.Values2D(sugar.TrimEmpty())
+		if err != nil || len(trimmed) != 2 || len(trimmed[0]) != 2 {
+			t.Errorf("expected trimmed 2x2 grid, got %v (%v)", trimmed, err)
+		}
+		return nil
+	})
+}
+
+func TestChain_SetValues2D(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := excel.Get("Workbooks").Call("Add").Get("ActiveSheet")
+		data := [][]interface{}{
+			{"name", "qty", "ok"},
+			{"apple", 3, true},
+			{"pear", 1.5, nil},
+		}
+		if err := sheet.Get("Range", "A1:C3").SetValues2D(data).Err(); err != nil {
+			t.Fatalf("SetValues2D failed: %v", err)
+		}
+
+		grid, err := sheet.Get("Range", "A1:C3").Values2D()
+		if err != nil {
+			t.Fatalf("Values2D failed: %v", err)
+		}
+		if grid[0][1] != "qty" || grid[1][1] != float64(3) || grid[1][2] != true || grid[2][1] != 1.5 || grid[2][2] != nil {
+			t.Errorf("unexpected grid read back: %v", grid)
+		}
+
+		ragged := [][]interface{}{{1, 2}, {3}}
+		if err := sheet.Get("Range", "A1:B2").SetValues2D(ragged).Err(); err == nil {
+			t.Error("expected error for a ragged grid")
+		}
+		return nil
+	})
+}
+
+func TestChain_Coalesce(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		cell := excel.Get("Workbooks").Call("Add").Get("ActiveSheet").Get("Range", "A1")
+		cell.Put("Value", "found")
+
+		s, err := cell.Coalesce("NoSuchValue", "Value").GetString()
+		if err != nil || s != "found" {
+			t.Errorf("expected Coalesce to fall back to Value, got %q (%v)", s, err)
+		}
+
+		var comErr *sugar.ComError
+		if err := cell.Coalesce("NoSuchValue", "AlsoMissing").Err(); !errors.As(err, &comErr) || comErr.Member != "AlsoMissing" {
+			t.Errorf("expected the last member's error, got %v", err)
+		}
+		return nil
+	})
+}
+
+func TestChain_CacheDispIDs(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		sheetDisp, err := excel.Get("Workbooks").Call("Add").Get("ActiveSheet").Store()
+		if err != nil {
+			t.Fatalf("failed to store sheet: %v", err)
+		}
+		defer sheetDisp.Release()
+
+		cached := sugar.NewContext(ctx, sugar.CacheDispIDs(true))
+		defer cached.Release()
+
+		sheet := cached.From(sheetDisp)
+		for row := 1; row <= 20; row++ {
+			if err := sheet.Get("Cells", row, 1).Put("Value", row).Err(); err != nil {
+				t.Fatalf("row %d: %v", row, err)
+			}
+		}
+		n, err := sheet.Get("Cells", 20, 1).Get("Value").GetInt()
+		if err != nil || n != 20 {
+			t.Errorf("expected 20, got %d (%v)", n, err)
+		}
+		if err := sheet.Get("NoSuchMember").Err(); err == nil {
+			t.Error("expected error for unknown member with caching enabled")
+		}
+		return nil
+	})
+}
+
+func TestChain_DefaultValue(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		cell := excel.Get("Workbooks").Call("Add").Get("ActiveSheet").Get("Range", "A1")
+		if err := cell.SetDefaultValue("default").Err(); err != nil {
+			t.Fatalf("SetDefaultValue failed: %v", err)
+		}
+
+		v, err := cell.Get("Value").Value()
+		if err != nil || v != "default" {
+			t.Errorf("expected Value to be 'default', got %v (%v)", v, err)
+		}
+		v, err = cell.DefaultValue()
+		if err != nil || v != "default" {
+			t.Errorf("expected DefaultValue to be 'default', got %v (%v)", v, err)
+		}
+		return nil
+	})
+}
+
+func TestCollectProperties(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		workbooks := excel.Get("Workbooks")
+		workbooks.Call("Add")
+		workbooks.Call("Add")
+
+		rows, err := sugar.CollectProperties(workbooks, []string{"Name", "FullName"})
+		if err != nil {
+			t.Fatalf("CollectProperties failed: %v", err)
+		}
+		if len(rows) != 2 {
+			t.Fatalf("expected 2 rows, got %d", len(rows))
+		}
+		for i, row := range rows {
+			if name, ok := row["Name"].(string); !ok || name == "" {
+				t.Errorf("row %d: expected a Name, got %v", i, row["Name"])
+			}
+			if _, ok := row["FullName"].(string); !ok {
+				t.Errorf("row %d: expected a FullName, got %v", i, row["FullName"])
+			}
+		}
+
+		if _, err := sugar.CollectProperties(workbooks, []string{"NoSuchProperty"}); err == nil {
+			t.Error("expected error for an unknown property")
+		}
+		return nil
+	})
+}
+
+func TestChain_ReleaseAfter(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		deadline, cancel := context.WithCancel(context.Background())
+		wb := excel.Get("Workbooks").Call("Add").ReleaseAfter(deadline)
+		if err := wb.Get("Name").Err(); err != nil {
+			t.Fatalf("workbook unusable before cancel: %v", err)
+		}
+
+		cancel()
+		released := false
+		for i := 0; i < 50 && !released; i++ {
+			time.Sleep(10 * time.Millisecond)
+			// Any call through the Context releases due chains on this thread.
+			excel.Get("Ready")
+			released = wb.Get("Name").Err() != nil
+		}
+		if !released {
+			t.Error("expected the workbook chain to be released after cancel")
+		}
+		return nil
+	})
+}
+
+func TestChain_CallColumns(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		cols, err := excel.CallColumns("Evaluate", `{1,"a",TRUE,1.5;2,"b",FALSE,2}`)
+		if err != nil {
+			t.Fatalf("CallColumns failed: %v", err)
+		}
+		if len(cols) != 4 {
+			t.Fatalf("expected 4 columns, got %d", len(cols))
+		}
+		if _, ok := cols[0].Values.([]int64); !ok {
+			t.Errorf("expected []int64, got %T", cols[0].Values)
+		}
+		if _, ok := cols[1].Values.([]string); !ok {
+			t.Errorf("expected []string, got %T", cols[1].Values)
+		}
+		if _, ok := cols[2].Values.([]bool); !ok {
+			t.Errorf("expected []bool, got %T", cols[2].Values)
+		}
+		if _, ok := cols[3].Values.([]float64); !ok {
+			t.Errorf("expected []float64, got %T", cols[3].Values)
+		}
+		return nil
+	})
+}
+
+func TestChain_On(t *testing.T) {
+	sugar.With(context.Background()).WithMessagePump().Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := excel.Get("Workbooks").Call("Add").Get("ActiveSheet")
+
+		var changes int
+		var address string
+		unsubscribe, err := excel.On("SheetChange", func(args []interface{}) error {
+			changes++
+			if target, ok := args[1].(sugar.Chain); ok {
+				address, _ = target.Get("Address").GetString()
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("On failed: %v", err)
+		}
+
+		sheet.Get("Range", "B2").Put("Value", "changed")
+		sugar.Wait(ctx, 200*time.Millisecond)
+		if changes != 1 || address != "$B$2" {
+			t.Errorf("expected one SheetChange for $B$2, got %d for %q", changes, address)
+		}
+
+		unsubscribe()
+		sheet.Get("Range", "B3").Put("Value", "ignored")
+		sugar.Wait(ctx, 200*time.Millisecond)
+		if changes != 1 {
+			t.Errorf("expected no events after unsubscribing, got %d", changes)
+		}
+
+		if _, err := excel.On("NoSuchEvent", func([]interface{}) error { return nil }); err == nil {
+			t.Error("expected error for an unknown event")
+		}
+		return nil
+	})
+}
+
+func TestChain_CheckThread(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		if err := excel.CheckThread(); err != nil {
+			t.Errorf("expected no error on the creating thread, got %v", err)
+		}
+
+		errc := make(chan error)
+		go func() {
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+			errc <- excel.CheckThread()
+		}()
+		err := <-errc
+		if !errors.Is(err, sugar.ErrWrongThread) {
+			t.Fatalf("expected ErrWrongThread, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "marshal") {
+			t.Errorf("expected marshaling advice in %q", err)
+		}
+		return nil
+	})
+}
+
+type sheetVisibility int32
+
+const (
+	sheetHidden     sheetVisibility = 0
+	sheetVisible    sheetVisibility = -1
+	sheetVeryHidden sheetVisibility = 2
+)
+
+func TestGetEnum(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		sheets := excel.Get("Workbooks").Call("Add").Get("Worksheets")
+		sheets.Call("Add")
+		sheet := sheets.Index(1)
+
+		vis, err := sugar.GetEnum[sheetVisibility](sheet, "Visible")
+		if err != nil || vis != sheetVisible {
+			t.Fatalf("expected sheetVisible, got %d (%v)", vis, err)
+		}
+
+		if err := sugar.PutEnum(sheet, "Visible", sheetVeryHidden).Err(); err != nil {
+			t.Fatalf("PutEnum failed: %v", err)
+		}
+		if vis, _ := sugar.GetEnum[sheetVisibility](sheet, "Visible"); vis != sheetVeryHidden {
+			t.Errorf("expected sheetVeryHidden, got %d", vis)
+		}
+		sugar.PutEnum(sheet, "Visible", sheetVisible)
+		return nil
+	})
+}
+
+func TestContext_Stats(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		disp, err := excel.Fork().Store()
+		if err != nil {
+			t.Fatalf("failed to store application: %v", err)
+		}
+		defer disp.Release()
+
+		return ctx.Do(func(inner sugar.Context) error {
+			app := inner.From(disp)
+			app.Get("Workbooks")
+			app.Get("Workbooks")
+
+			got := inner.Stats()
+			want := sugar.ContextStats{Tracked: 3, Roots: 1, Derived: 2}
+			if got != want {
+				t.Errorf("expected %+v, got %+v", want, got)
+			}
+			if inner.Len() != got.Tracked {
+				t.Errorf("Len %d does not match Stats.Tracked %d", inner.Len(), got.Tracked)
+			}
+			return nil
+		})
+	})
+}
+
+func TestChain_Cancellation(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		wbs := excel.Get("Workbooks")
+		wbs.Call("Add")
+		wbs.Call("Add")
+
+		disp, err := wbs.Fork().Store()
+		if err != nil {
+			t.Fatalf("failed to store workbooks: %v", err)
+		}
+		defer disp.Release()
+
+		cctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		return sugar.With(cctx).Do(func(inner sugar.Context) error {
+			books := inner.From(disp)
+
+			visited := 0
+			err := books.ForEach(func(item sugar.Chain) error {
+				visited++
+				cancel()
+				return nil
+			}).Err()
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected ForEach to stop with context.Canceled, got %v", err)
+			}
+			if visited != 1 {
+				t.Errorf("expected ForEach to stop after 1 item, visited %d", visited)
+			}
+
+			_, err = books.Get("Count").Value()
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected Get to fail with context.Canceled, got %v", err)
+			}
+			return nil
+		})
+	})
+}
+
+func TestChain_WithTimeout(t *testing.T) {
+	calls := 0
+	err := sugar.With(context.Background()).WithTimeout(500 * time.Millisecond).Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+
+		// Chains of the timed-out Context can no longer call Quit, so keep an
+		// untracked reference for cleanup.
+		disp, err := excel.Fork().Store()
+		if err != nil {
+			t.Fatalf("failed to store application: %v", err)
+		}
+		defer func() {
+			app := sugar.From(disp)
+			app.Put("DisplayAlerts", false).Call("Quit")
+			app.Release()
+			disp.Release()
+		}()
+
+		for {
+			if _, err := excel.Get("Version").Value(); err != nil {
+				return err
+			}
+			calls++
+		}
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if calls == 0 {
+		t.Error("expected some calls to succeed before the deadline")
+	}
+}
+
+func TestCreateCLSID(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		clsid, err := sugar.ParseCLSID("{00024500-0000-0000-C000-000000000046}") // Excel.Application
+		if err != nil {
+			t.Fatal(err)
+		}
+		excel := ctx.CreateCLSID(clsid)
+		if err := excel.Err(); err != nil {
+			t.Skip("Excel not installed:", err)
+			return nil
+		}
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		if name, err := excel.Get("Name").GetString(); err != nil || name != "Microsoft Excel" {
+			t.Errorf("expected Microsoft Excel, got %q (%v)", name, err)
+		}
+		return nil
+	})
+}
+
+func TestChain_CallNamed(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		sheets := excel.Get("Workbooks").Call("Add").Get("Worksheets")
+		before, _ := sheets.Get("Count").GetInt()
+
+		if err := sheets.CallNamed("Add", map[string]interface{}{"Count": 2}).Err(); err != nil {
+			t.Fatalf("CallNamed failed: %v", err)
+		}
+		if after, _ := sheets.Get("Count").GetInt(); after != before+2 {
+			t.Errorf("expected %d sheets, got %d", before+2, after)
+		}
+
+		if err := sheets.CallNamed("Add", map[string]interface{}{"NoSuchParam": 1}).Err(); err == nil {
+			t.Error("expected error for an unknown parameter name")
+		}
+
+		if err := excel.PutNamed("DisplayAlerts", false, nil).Err(); err != nil {
+			t.Errorf("PutNamed failed: %v", err)
+		}
+		if on, _ := excel.Get("DisplayAlerts").GetBool(); on {
+			t.Error("expected DisplayAlerts to be false after PutNamed")
+		}
+		return nil
+	})
+}
+
+func TestChain_Dump(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		// Some properties fail without an active workbook; the map is still
+		// returned.
+		props, err := excel.Dump()
+		if props == nil {
+			t.Fatalf("Dump failed: %v", err)
+		}
+		if props["Name"] != "Microsoft Excel" {
+			t.Errorf("expected Name to be Microsoft Excel, got %v", props["Name"])
+		}
+		if _, ok := props["Workbooks"]; ok {
+			t.Error("expected object-typed Workbooks to be skipped")
+		}
+		return nil
+	})
+}
+
+func TestChain_Members(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		members, err := excel.Members()
+		if err != nil {
+			t.Fatalf("Members failed: %v", err)
+		}
+		byName := make(map[string]sugar.MemberInfo)
+		for _, m := range members {
+			byName[m.Name] = m
+		}
+		if m := byName["Quit"]; m.Kind != sugar.MemberMethod {
+			t.Errorf("expected Quit to be a method, got %+v", m)
+		}
+		if m := byName["Visible"]; m.Kind != sugar.MemberProperty || !m.Readable || !m.Writable {
+			t.Errorf("expected Visible to be a read/write property, got %+v", m)
+		}
+		if m := byName["Workbooks"]; m.Kind != sugar.MemberProperty || !m.Readable || m.Writable {
+			t.Errorf("expected Workbooks to be a read-only property, got %+v", m)
+		}
+		if _, ok := byName["QueryInterface"]; ok {
+			t.Error("expected restricted IUnknown methods to be left out")
+		}
+		return nil
+	})
+}
+
+func TestSuggestMembers(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		var comErr *sugar.ComError
+		if err := excel.Get("Visble").Err(); !errors.As(err, &comErr) || comErr.Suggestion != "" {
+			t.Errorf("expected no suggestion by default, got %v", err)
+		}
+
+		appDisp, err := excel.Store()
+		if err != nil {
+			t.Fatalf("failed to store application: %v", err)
+		}
+		defer appDisp.Release()
+
+		suggesting := sugar.NewContext(ctx, sugar.SuggestMembers())
+		defer suggesting.Release()
+		app := suggesting.From(appDisp)
+
+		err = app.Get("Visble").Err()
+		if !errors.As(err, &comErr) || comErr.Suggestion != "Visible" {
+			t.Fatalf("expected a suggestion of Visible, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "did you mean Visible?") {
+			t.Errorf("expected the suggestion in the message, got %q", err)
+		}
+		if err := app.Get("Xyzzy").Err(); !errors.As(err, &comErr) || comErr.Suggestion != "" {
+			t.Errorf("expected no suggestion for an unrelated name, got %v", err)
+		}
+		return nil
+	})
+}
+
+func TestChain_ReleaseTwice(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		wbDisp, err := excel.Get("Workbooks").Call("Add").Store()
+		if err != nil {
+			t.Fatalf("failed to store workbook: %v", err)
+		}
+		defer wbDisp.Release()
+
+		inner := sugar.NewContext(ctx)
+		wb := inner.From(wbDisp)
+		inner.Track(wb) // tracked twice
+		if err := wb.Release(); err != nil {
+			t.Errorf("first Release failed: %v", err)
+		}
+		if err := wb.Release(); err != nil {
+			t.Errorf("second Release failed: %v", err)
+		}
+		if err := inner.Release(); err != nil {
+			t.Errorf("Context.Release failed: %v", err)
+		}
+
+		// The stored reference must still be alive.
+		if _, err := ctx.From(wbDisp).Get("Name").GetString(); err != nil {
+			t.Errorf("stored workbook was over-released: %v", err)
+		}
+		return nil
+	})
+}
+
+func TestChain_IsNil(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		excel := setupExcel(t, ctx)
+		if excel == nil { return nil }
+		defer excel.Put("DisplayAlerts", false).Call("Quit")
+
+		sheet := excel.Get("Workbooks").Call("Add").Get("ActiveSheet")
+		if value := sheet.Get("Range", "A1").Get("Value"); !value.IsEmpty() || value.IsNil() {
+			t.Error("expected a blank cell to be empty")
+		}
+
+		sheet.Get("Range", "B1").Get("Font").Put("Bold", true)
+		if bold := sheet.Get("Range", "A1:B1").Get("Font").Get("Bold"); !bold.IsNil() || bold.IsEmpty() {
+			t.Error("expected Bold of mixed formatting to be null")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,56 @@
+//go:build windows && go1.23
+
+package sugar_test
+
+import (
+	"testing"
+
+	"github.com/xll-gen/sugar"
+	"github.com/xll-gen/sugar/sugartest"
+)
+
+func TestChain_All(t *testing.T) {
+	books := sugartest.NewCollection(
+		sugartest.NewObject().Set("Name", "Book1"),
+		sugartest.NewObject().Set("Name", "Book2"),
+		sugartest.NewObject().Set("Name", "Book3"),
+	)
+	sugar.Do(func(ctx sugar.Context) error {
+		coll := ctx.FromDispatcher(books)
+
+		var names []string
+		for item := range coll.All() {
+			name, err := item.Get("Name").GetString()
+			if err != nil {
+				t.Fatalf("Get Name failed: %v", err)
+			}
+			names = append(names, name)
+			if len(names) == 2 {
+				break
+			}
+		}
+		if len(names) != 2 || names[1] != "Book2" {
+			t.Errorf("expected to stop after Book2, got %v", names)
+		}
+
+		last := -1
+		for i := range coll.All2() {
+			last = i
+		}
+		if last != 2 {
+			t.Errorf("expected last position 2, got %d", last)
+		}
+
+		n := 0
+		for item := range ctx.FromDispatcher(sugartest.NewObject()).All() {
+			n++
+			if item.Err() == nil {
+				t.Error("expected an error chain for a non-collection")
+			}
+		}
+		if n != 1 {
+			t.Errorf("expected a single error chain, got %d items", n)
+		}
+		return nil
+	})
+}
@@ -3,11 +3,20 @@
 package sugar_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"math/big"
+	"reflect"
+	"runtime"
 	"testing"
+	"time"
 
+	"github.com/go-ole/go-ole"
 	"github.com/xll-gen/sugar"
+	"github.com/xll-gen/sugar/sugartest"
 )
 
 func ExampleDo() {
@@ -64,4 +73,770 @@ func TestChain_Mock(t *testing.T) {
 			return nil
 		})
 	})
-}
\ No newline at end of file
+}
+func TestResult(t *testing.T) {
+	ok := sugar.Result[int]{Value: 7}
+	if v := ok.OrElse(1); v != 7 {
+		t.Errorf("OrElse: expected 7, got %d", v)
+	}
+	if v, err := ok.Unwrap(); v != 7 || err != nil {
+		t.Errorf("Unwrap: expected (7, nil), got (%d, %v)", v, err)
+	}
+	if v := ok.Must(); v != 7 {
+		t.Errorf("Must: expected 7, got %d", v)
+	}
+
+	failed := sugar.AsResult[int](sugar.From(nil), "Count")
+	if failed.Err == nil {
+		t.Fatal("expected error for nil dispatch")
+	}
+	if v := failed.OrElse(1); v != 1 {
+		t.Errorf("OrElse: expected fallback 1, got %d", v)
+	}
+	if _, err := failed.Unwrap(); err == nil {
+		t.Error("Unwrap: expected error")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Must: expected panic")
+		}
+	}()
+	failed.Must()
+}
+
+func ExampleChain_AutoRelease() {
+	sugar.Do(func(ctx sugar.Context) error {
+		// Chains created without a Context can opt into finalizer-based cleanup.
+		excel := sugar.Create("Excel.Application").AutoRelease()
+		if err := excel.Err(); err != nil {
+			return err
+		}
+		defer excel.Call("Quit")
+
+		// Derived chains inherit AutoRelease.
+		name, _ := excel.Get("Workbooks").Call("Add").Get("Name").Value()
+		fmt.Printf("Workbook Name: %v\n", name)
+		return nil
+	})
+}
+
+func TestTrimTrailingEmpty(t *testing.T) {
+	grid := [][]interface{}{
+		{"a", nil, 1.0, nil},
+		{nil, "b", nil, nil},
+		{nil, nil, nil, nil},
+		{nil, nil, nil, nil},
+	}
+	got := sugar.TrimTrailingEmpty(grid)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(got))
+	}
+	for i, row := range got {
+		if len(row) != 3 {
+			t.Errorf("row %d: expected 3 columns, got %d", i, len(row))
+		}
+	}
+	if got[0][2] != 1.0 || got[1][1] != "b" {
+		t.Errorf("unexpected trimmed content: %v", got)
+	}
+
+	if got := sugar.TrimTrailingEmpty([][]interface{}{{nil}, {nil}}); len(got) != 0 {
+		t.Errorf("expected all-empty grid to trim to nothing, got %v", got)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	grid := [][]interface{}{
+		{"id", "name", "price", "active", "note"},
+		{1.0, "apple", 1.5, true, "x"},
+		{2.0, "pear", 2.0, false, 3.0},
+		{nil, "plum", nil, nil, nil},
+	}
+	cols := sugar.Columns(grid, true)
+	if len(cols) != 5 {
+		t.Fatalf("expected 5 columns, got %d", len(cols))
+	}
+
+	want := []interface{}{
+		[]int64{1, 2, 0},
+		[]string{"apple", "pear", "plum"},
+		[]float64{1.5, 2.0, 0},
+		[]bool{true, false, false},
+		[]interface{}{"x", 3.0, nil},
+	}
+	for i, col := range cols {
+		if col.Index != i || col.Name != grid[0][i] {
+			t.Errorf("column %d: unexpected index/name %d/%q", i, col.Index, col.Name)
+		}
+		if !reflect.DeepEqual(col.Values, want[i]) {
+			t.Errorf("column %q: expected %#v, got %#v", col.Name, want[i], col.Values)
+		}
+	}
+}
+
+func TestParseCLSID(t *testing.T) {
+	for _, s := range []string{
+		"{00024500-0000-0000-C000-000000000046}",
+		"00024500-0000-0000-C000-000000000046",
+	} {
+		guid, err := sugar.ParseCLSID(s)
+		if err != nil {
+			t.Errorf("ParseCLSID(%q) failed: %v", s, err)
+			continue
+		}
+		if got := guid.String(); got != "{00024500-0000-0000-C000-000000000046}" {
+			t.Errorf("ParseCLSID(%q) = %s", s, got)
+		}
+	}
+	if _, err := sugar.ParseCLSID("Excel.Application"); err == nil {
+		t.Error("expected error for a ProgID")
+	}
+}
+
+func TestChain_QueryInterface(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		obj := ctx.FromDispatcher(sugartest.NewObject().Set("Name", "fake"))
+
+		disp, err := obj.QueryInterface(ole.IID_IDispatch)
+		if err != nil {
+			t.Fatalf("QueryInterface(IDispatch) failed: %v", err)
+		}
+		if name, err := disp.Get("Name").GetString(); err != nil || name != "fake" {
+			t.Errorf("expected fake, got %q (%v)", name, err)
+		}
+
+		if _, err := obj.QueryInterface(ole.IID_IConnectionPointContainer); err == nil {
+			t.Error("expected QueryInterface to fail for an unsupported interface")
+		}
+		return nil
+	})
+}
+
+func TestMissing(t *testing.T) {
+	var got []interface{}
+	books := sugartest.NewObject().OnCall("Open", func(args ...interface{}) (interface{}, error) {
+		got = args
+		return nil, nil
+	})
+	sugar.Do(func(ctx sugar.Context) error {
+		if err := ctx.FromDispatcher(books).Call("Open", "book.xlsx", sugar.Missing, true).Err(); err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		return nil
+	})
+	if len(got) != 3 || got[0] != "book.xlsx" || got[1] != sugar.Missing || got[2] != true {
+		t.Errorf("expected [book.xlsx Missing true], got %v", got)
+	}
+}
+
+func TestSetTracer(t *testing.T) {
+	type call struct {
+		op, member string
+		failed     bool
+	}
+	var calls []call
+	sugar.SetTracer(func(op, member string, params []interface{}, elapsed time.Duration, err error) {
+		if elapsed < 0 {
+			t.Errorf("negative elapsed time %v", elapsed)
+		}
+		calls = append(calls, call{op, member, err != nil})
+	})
+	defer sugar.SetTracer(nil)
+
+	sugar.Do(func(ctx sugar.Context) error {
+		obj := ctx.FromDispatcher(sugartest.NewObject().Set("Name", "fake"))
+		obj.Get("Name")
+		obj.Call("Missing")
+		return nil
+	})
+
+	want := []call{{sugar.OpGet, "Name", false}, {sugar.OpCall, "Missing", true}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("expected %v, got %v", want, calls)
+	}
+}
+
+func TestChain_PutRef(t *testing.T) {
+	conn := sugartest.NewObject().Set("Name", "conn")
+	rs := sugartest.NewObject().Set("ActiveConnection", nil)
+	sugar.Do(func(ctx sugar.Context) error {
+		if err := ctx.FromDispatcher(rs).PutRef("ActiveConnection", ctx.FromDispatcher(conn)).Err(); err != nil {
+			t.Fatalf("PutRef failed: %v", err)
+		}
+		return nil
+	})
+	if got, _ := rs.Prop("ActiveConnection"); got != conn {
+		t.Errorf("expected the connection object to be assigned, got %v", got)
+	}
+}
+
+func TestChain_DumpWithoutTypeInfo(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		props, err := ctx.FromDispatcher(sugartest.NewObject().Set("Name", "fake")).Dump()
+		if err == nil || props != nil {
+			t.Errorf("expected an error for an object without type information, got %v", props)
+		}
+		return nil
+	})
+}
+
+func TestValueAs(t *testing.T) {
+	type rowCount uint16
+	obj := sugartest.NewObject().
+		Set("Count", 42).
+		Set("Ratio", 2.5).
+		Set("Name", "fake").
+		Set("Big", 70000)
+
+	sugar.Do(func(ctx sugar.Context) error {
+		o := ctx.FromDispatcher(obj)
+
+		if n, err := sugar.ValueAs[int64](o.Get("Count")); err != nil || n != 42 {
+			t.Errorf("expected 42, got %d (%v)", n, err)
+		}
+		if f, err := sugar.ValueAs[float64](o.Get("Count")); err != nil || f != 42 {
+			t.Errorf("expected 42.0, got %v (%v)", f, err)
+		}
+		if n, err := sugar.ValueAs[rowCount](o.Get("Count")); err != nil || n != 42 {
+			t.Errorf("expected rowCount 42, got %d (%v)", n, err)
+		}
+		if s, err := sugar.ValueAs[string](o.Get("Name")); err != nil || s != "fake" {
+			t.Errorf("expected fake, got %q (%v)", s, err)
+		}
+
+		if n, err := sugar.ValueAs[int](o.Get("Ratio")); err == nil || n != 0 {
+			t.Errorf("expected an error and zero for a fractional value, got %d", n)
+		}
+		if _, err := sugar.ValueAs[rowCount](o.Get("Big")); err == nil {
+			t.Error("expected an overflow error")
+		}
+		if _, err := sugar.ValueAs[string](o.Get("Missing")); err == nil {
+			t.Error("expected the chain's error")
+		}
+		return nil
+	})
+}
+
+func TestChain_IsEmpty(t *testing.T) {
+	obj := sugartest.NewObject().Set("Note", nil).Set("Name", "fake")
+	sugar.Do(func(ctx sugar.Context) error {
+		o := ctx.FromDispatcher(obj)
+		if o.IsEmpty() || o.IsNil() {
+			t.Error("expected a chain without a result to be neither empty nor nil")
+		}
+		if note := o.Get("Note"); !note.IsEmpty() || note.IsNil() {
+			t.Error("expected an unset property to be empty")
+		}
+		if name := o.Get("Name"); name.IsEmpty() || name.IsNil() {
+			t.Error("expected a string property to be neither empty nor nil")
+		}
+		return nil
+	})
+}
+
+func TestChain_CallOut(t *testing.T) {
+	var got []interface{}
+	obj := sugartest.NewObject().OnCall("Lookup", func(args ...interface{}) (interface{}, error) {
+		got = args
+		return "done", nil
+	})
+
+	sugar.Do(func(ctx sugar.Context) error {
+		o := ctx.FromDispatcher(obj)
+
+		name, found, count := "in", true, 7
+		var any interface{} = "x"
+		outs, res := o.CallOut("Lookup", 1, &name, &found, &count, &any)
+		if err := res.Err(); err != nil {
+			t.Fatalf("CallOut failed: %v", err)
+		}
+		want := []interface{}{int32(1), "in", true, int32(7), "x"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected the server to receive %v, got %v", want, got)
+		}
+		if !reflect.DeepEqual(outs, []interface{}{"in", true, 7, "x"}) {
+			t.Errorf("unexpected out values %v", outs)
+		}
+		if s, _ := res.GetString(); s != "done" {
+			t.Errorf("expected the result done, got %q", s)
+		}
+
+		var unsupported []int
+		if _, res := o.CallOut("Lookup", &unsupported); res.Err() == nil {
+			t.Error("expected an error for an unsupported pointer type")
+		}
+		return nil
+	})
+}
+
+func TestChain_CallSlice(t *testing.T) {
+	obj := sugartest.NewObject().
+		OnCall("Quotes", func(args ...interface{}) (interface{}, error) {
+			return []interface{}{"EURUSD", 1.085, int32(3)}, nil
+		}).
+		OnCall("Grid", func(args ...interface{}) (interface{}, error) {
+			return [][]interface{}{{1, 2}}, nil
+		}).
+		OnCall("Scalar", func(args ...interface{}) (interface{}, error) {
+			return 42, nil
+		})
+
+	sugar.Do(func(ctx sugar.Context) error {
+		o := ctx.FromDispatcher(obj)
+
+		got, err := o.CallSlice("Quotes")
+		if err != nil {
+			t.Fatalf("CallSlice failed: %v", err)
+		}
+		if want := []interface{}{"EURUSD", 1.085, int32(3)}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+		if _, err := o.CallSlice("Grid"); err == nil {
+			t.Error("expected an error for a two-dimensional array")
+		}
+		if _, err := o.CallSlice("Scalar"); err == nil {
+			t.Error("expected an error for a scalar result")
+		}
+		return nil
+	})
+}
+
+func TestChain_CurrencyDecimal(t *testing.T) {
+	obj := sugartest.NewObject().Set("Price", 0).Set("Rate", 0)
+
+	sugar.Do(func(ctx sugar.Context) error {
+		o := ctx.FromDispatcher(obj)
+
+		price := sugar.Currency(-1234567) // -123.4567
+		if err := o.Put("Price", price).Err(); err != nil {
+			t.Fatalf("Put Currency failed: %v", err)
+		}
+		got, err := o.Get("Price").Value()
+		if err != nil || got != price {
+			t.Errorf("expected %v, got %v (%T, %v)", price, got, got, err)
+		}
+		if s := price.String(); s != "-123.4567" {
+			t.Errorf("expected -123.4567, got %s", s)
+		}
+		if f, _ := o.Get("Price").GetFloat(); f != -123.4567 {
+			t.Errorf("expected -123.4567, got %v", f)
+		}
+
+		rate, _ := new(big.Rat).SetString("79228162514264337593543950.335")
+		if err := o.Put("Rate", rate).Err(); err != nil {
+			t.Fatalf("Put *big.Rat failed: %v", err)
+		}
+		v, err := o.Get("Rate").Value()
+		if r, ok := v.(*big.Rat); err != nil || !ok || r.Cmp(rate) != 0 {
+			t.Errorf("expected %v, got %v (%T, %v)", rate.RatString(), v, v, err)
+		}
+
+		if err := o.Put("Rate", big.NewRat(1, 3)).Err(); err == nil {
+			t.Error("expected an error for a value without an exact decimal representation")
+		}
+		return nil
+	})
+}
+
+func TestChain_BorrowedOutlivesAutoReleaseParent(t *testing.T) {
+	obj := sugartest.NewObject().Set("Name", "Book1")
+
+	sugar.Do(func(ctx sugar.Context) error {
+		// AutoRelease only applies to chains outside a Context.
+		parent := sugar.FromDispatcher(obj).AutoRelease()
+		child := parent.Get("Name")
+		parent = nil
+		// Give an AutoRelease finalizer on the parent every chance to run.
+		for i := 0; i < 3; i++ {
+			runtime.GC()
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		disp, err := child.Get("Name").Store()
+		if err != nil {
+			t.Fatalf("Store failed: %v", err)
+		}
+		if n := disp.Release(); n != 1 {
+			t.Errorf("expected the parent to still hold its reference, got count %d", n)
+		}
+		if s, err := child.Get("Name").GetString(); err != nil || s != "Book1" {
+			t.Errorf("expected Book1, got %q (%v)", s, err)
+		}
+		runtime.KeepAlive(child)
+		return nil
+	})
+}
+
+func TestChain_Observe(t *testing.T) {
+	obj := sugartest.NewObject().Set("Value", "before")
+
+	var closedOnRelease <-chan interface{}
+	sugar.Do(func(ctx sugar.Context) error {
+		o := ctx.FromDispatcher(obj)
+		values, stop := o.Observe("Value", 10*time.Millisecond)
+
+		// The first poll records the baseline without sending it.
+		sugar.Wait(ctx, 30*time.Millisecond)
+		select {
+		case v := <-values:
+			t.Fatalf("expected no value before a change, got %v", v)
+		default:
+		}
+
+		for _, want := range []interface{}{"after", int32(7)} {
+			obj.Set("Value", want)
+			sugar.Wait(ctx, 30*time.Millisecond)
+			select {
+			case v := <-values:
+				if v != want {
+					t.Errorf("expected %v (%T), got %v (%T)", want, want, v, v)
+				}
+			default:
+				t.Fatalf("expected the change to %v to be observed", want)
+			}
+		}
+
+		if err := stop(); err != nil {
+			t.Errorf("expected stop to report no error, got %v", err)
+		}
+		if _, ok := <-values; ok {
+			t.Error("expected the channel to be closed after stop")
+		}
+
+		missing, stopMissing := o.Observe("Missing", 10*time.Millisecond)
+		sugar.Wait(ctx, 30*time.Millisecond)
+		if _, ok := <-missing; ok {
+			t.Error("expected the channel to be closed after a failed read")
+		}
+		if err := stopMissing(); err == nil {
+			t.Error("expected stop to report the failed read")
+		}
+
+		closedOnRelease, _ = o.Observe("Value", 10*time.Millisecond)
+		return nil
+	})
+	if _, ok := <-closedOnRelease; ok {
+		t.Error("expected the channel to be closed when the Context is released")
+	}
+}
+
+func TestChain_IndexArgs(t *testing.T) {
+	var got []interface{}
+	obj := sugartest.NewObject().OnCall("Item", func(args ...interface{}) (interface{}, error) {
+		got = args
+		return "item", nil
+	})
+
+	sugar.Do(func(ctx sugar.Context) error {
+		o := ctx.FromDispatcher(obj)
+		when := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		for _, key := range []interface{}{sugar.Currency(15000), when, o} {
+			if err := o.Index(key).Err(); err != nil {
+				t.Errorf("Index(%T) failed: %v", key, err)
+			}
+		}
+		if len(got) != 1 || got[0] != obj {
+			t.Errorf("expected a Chain key to arrive as its Dispatcher, got %v", got)
+		}
+		return nil
+	})
+
+	stdCtx, cancel := context.WithCancel(context.Background())
+	sugar.With(stdCtx).Do(func(ctx sugar.Context) error {
+		o := ctx.FromDispatcher(obj)
+		got = nil
+		cancel()
+		if err := o.Index(1).Err(); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if got != nil {
+			t.Error("expected Index not to call the object after cancellation")
+		}
+		return nil
+	})
+}
+
+func TestChain_DefaultValueCancelled(t *testing.T) {
+	calls := 0
+	obj := sugartest.NewObject().OnCall("", func(args ...interface{}) (interface{}, error) {
+		calls++
+		return "default", nil
+	})
+
+	stdCtx, cancel := context.WithCancel(context.Background())
+	sugar.With(stdCtx).Do(func(ctx sugar.Context) error {
+		o := ctx.FromDispatcher(obj)
+		if v, err := o.DefaultValue(); err != nil || v != "default" {
+			t.Fatalf("expected default, got %v (%v)", v, err)
+		}
+		cancel()
+		if _, err := o.DefaultValue(); !errors.Is(err, context.Canceled) {
+			t.Errorf("DefaultValue: expected context.Canceled, got %v", err)
+		}
+		if err := o.SetDefaultValue("x").Err(); !errors.Is(err, context.Canceled) {
+			t.Errorf("SetDefaultValue: expected context.Canceled, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected no calls after cancellation, got %d in total", calls)
+		}
+		return nil
+	})
+}
+
+func TestGetOrCreate(t *testing.T) {
+	sheet := sugartest.NewObject().Set("Name", "Data")
+	sheets := sugartest.NewCollection(sheet)
+	denied := sugartest.NewObject().OnCall("Item", func(args ...interface{}) (interface{}, error) {
+		return nil, ole.NewError(0x80070005) // E_ACCESSDENIED
+	})
+
+	sugar.Do(func(ctx sugar.Context) error {
+		created := 0
+		create := func(coll sugar.Chain) sugar.Chain {
+			created++
+			return ctx.FromDispatcher(sugartest.NewObject().Set("Name", "New"))
+		}
+
+		coll := ctx.FromDispatcher(sheets)
+		if name, _ := sugar.GetOrCreate(coll, "Data", create).Get("Name").GetString(); name != "Data" || created != 0 {
+			t.Errorf("expected the existing item, got %q after %d creates", name, created)
+		}
+		if name, _ := sugar.GetOrCreate(coll, "Missing", create).Get("Name").GetString(); name != "New" || created != 1 {
+			t.Errorf("expected a created item, got %q after %d creates", name, created)
+		}
+
+		err := sugar.GetOrCreate(ctx.FromDispatcher(denied), "Data", create).Err()
+		if err == nil {
+			t.Error("expected the access denied error to be returned")
+		}
+		if created != 1 {
+			t.Error("expected create not to run for an unrelated error")
+		}
+		return nil
+	})
+}
+
+func TestChain_ForEachBreakValue(t *testing.T) {
+	books := sugartest.NewCollection(
+		sugartest.NewObject().Set("Name", "Book1"),
+		sugartest.NewObject().Set("Name", "Book2"),
+		sugartest.NewObject().Set("Name", "Book3"),
+	)
+
+	sugar.Do(func(ctx sugar.Context) error {
+		coll := ctx.FromDispatcher(books)
+
+		visited := 0
+		res := coll.ForEach(func(item sugar.Chain) error {
+			visited++
+			name, _ := item.Get("Name").GetString()
+			if name == "Book2" {
+				return fmt.Errorf("found: %w", &sugar.ForEachBreak{Value: name})
+			}
+			return nil
+		})
+		if visited != 2 {
+			t.Errorf("expected the loop to stop at the second item, visited %d", visited)
+		}
+		// The break and its value stay on the returned chain and on chains
+		// derived from it.
+		for _, ch := range []sugar.Chain{res, res.Get("Name")} {
+			var brk *sugar.ForEachBreak
+			if !errors.As(ch.Err(), &brk) {
+				t.Fatalf("expected a ForEachBreak, got %v", ch.Err())
+			}
+			if brk.Value != "Book2" {
+				t.Errorf("expected break value Book2, got %v", brk.Value)
+			}
+		}
+
+		var found string
+		res = coll.ForEachUntil(func(item sugar.Chain) bool {
+			found, _ = item.Get("Name").GetString()
+			return found != "Book2"
+		})
+		if err := res.Err(); err != nil {
+			t.Errorf("expected no error from ForEachUntil, got %v", err)
+		}
+		if found != "Book2" {
+			t.Errorf("expected ForEachUntil to stop at Book2, got %q", found)
+		}
+		return nil
+	})
+}
+
+func TestChain_CallBool(t *testing.T) {
+	obj := sugartest.NewObject().
+		Set("Visible", false).
+		OnCall("Result", func(args ...interface{}) (interface{}, error) {
+			return args[0], nil
+		}).
+		OnCall("Object", func(args ...interface{}) (interface{}, error) {
+			return sugartest.NewObject(), nil
+		}).
+		OnCall("Fail", func(args ...interface{}) (interface{}, error) {
+			return nil, errors.New("failed")
+		})
+
+	sugar.Do(func(ctx sugar.Context) error {
+		o := ctx.FromDispatcher(obj)
+
+		for _, tc := range []struct {
+			name   string
+			result interface{}
+			want   bool
+		}{
+			{"VT_BOOL true", true, true},
+			{"VT_BOOL false", false, false},
+			{"int32 non-zero", int32(-1), true},
+			{"int32 zero", int32(0), false},
+			{"double non-zero", 0.5, true},
+			{"double zero", 0.0, false},
+		} {
+			if b, err := o.CallBool("Result", tc.result); err != nil || b != tc.want {
+				t.Errorf("%s: expected %v, got %v (%v)", tc.name, tc.want, b, err)
+			}
+		}
+
+		if _, err := o.CallBool("Result", "yes"); err == nil {
+			t.Error("expected an error for a string result")
+		}
+		if _, err := o.CallBool("Object"); err == nil {
+			t.Error("expected an error for an object result")
+		}
+		if _, err := o.CallBool("Fail"); err == nil {
+			t.Error("expected the error of the method")
+		}
+
+		// Property form.
+		if b, err := o.Get("Visible").GetBool(); err != nil || b {
+			t.Errorf("expected Visible to be false, got %v (%v)", b, err)
+		}
+		return nil
+	})
+}
+
+func TestChain_CheckThreadsMTA(t *testing.T) {
+	obj := sugartest.NewObject().Set("Name", "Book1")
+
+	err := sugar.With(context.Background()).WithApartment(sugar.MTA).Do(func(ctx sugar.Context) error {
+		checked := sugar.NewContext(ctx, sugar.CheckThreads())
+		defer checked.Release()
+
+		o := checked.FromDispatcher(obj)
+		// The goroutine is not locked in the MTA, so yielding lets the
+		// scheduler move it to other threads between calls.
+		for i := 0; i < 1000; i++ {
+			runtime.Gosched()
+			if _, err := o.Get("Name").GetString(); err != nil {
+				return fmt.Errorf("call %d: %w", i, err)
+			}
+			if err := o.CheckThread(); err != nil {
+				return fmt.Errorf("call %d: %w", i, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected MTA chains to pass CheckThreads, got %v", err)
+	}
+}
+
+func TestChain_ExceptionSCODE(t *testing.T) {
+	obj := sugartest.NewObject().OnCall("Fail", func(args ...interface{}) (interface{}, error) {
+		return nil, ole.NewError(0x800A03EC)
+	})
+
+	sugar.Do(func(ctx sugar.Context) error {
+		o := ctx.FromDispatcher(obj)
+		_, out := o.CallOut("Fail")
+		for name, err := range map[string]error{
+			"CallNamed": o.CallNamed("Fail", nil).Err(),
+			"CallOut":   out.Err(),
+		} {
+			var oleErr *ole.OleError
+			if !errors.As(err, &oleErr) {
+				t.Errorf("%s: expected an *ole.OleError, got %v", name, err)
+				continue
+			}
+			if code := oleErr.Code(); code != 0x80020009 {
+				t.Errorf("%s: expected DISP_E_EXCEPTION, got 0x%08X", name, code)
+			}
+			exc, ok := oleErr.SubError().(interface{ SCODE() uint32 })
+			if !ok || exc.SCODE() != 0x800A03EC {
+				t.Errorf("%s: expected the exception to carry SCODE 0x800A03EC, got %v", name, oleErr.SubError())
+			}
+		}
+		return nil
+	})
+}
+
+func TestMap_UntracksItems(t *testing.T) {
+	books := sugartest.NewCollection(
+		sugartest.NewObject().Set("Name", "Book1"),
+		sugartest.NewObject().Set("Name", "Book2"),
+	)
+
+	sugar.Do(func(ctx sugar.Context) error {
+		coll := ctx.FromDispatcher(books)
+		tracked := ctx.Len()
+
+		names, err := sugar.Map(coll, func(item sugar.Chain) (string, error) {
+			return item.Get("Name").GetString()
+		})
+		if err != nil {
+			t.Fatalf("Map failed: %v", err)
+		}
+		if !reflect.DeepEqual(names, []string{"Book1", "Book2"}) {
+			t.Errorf("unexpected names %v", names)
+		}
+		// Released items must no longer be tracked, or the Context would
+		// release them again.
+		if got := ctx.Len(); got != tracked {
+			t.Errorf("expected %d tracked chains after Map, got %d", tracked, got)
+		}
+		return nil
+	})
+}
+
+func TestChain_GetIntFloatBounds(t *testing.T) {
+	const twoTo63 = 9223372036854775808.0
+	obj := sugartest.NewObject().
+		Set("Min", -twoTo63).
+		Set("BelowMax", math.Nextafter(twoTo63, 0)).
+		Set("Overflow", twoTo63)
+
+	sugar.Do(func(ctx sugar.Context) error {
+		o := ctx.FromDispatcher(obj)
+		if n, err := o.Get("Min").GetInt(); err != nil || n != math.MinInt64 {
+			t.Errorf("expected MinInt64, got %d (%v)", n, err)
+		}
+		if n, err := o.Get("BelowMax").GetInt(); err != nil || n != 1<<63-1024 {
+			t.Errorf("expected the largest float64 below 2^63, got %d (%v)", n, err)
+		}
+		if n, err := o.Get("Overflow").GetInt(); err == nil {
+			t.Errorf("expected an error for 2^63, got %d", n)
+		}
+		return nil
+	})
+}
+
+func TestChain_IsNothing(t *testing.T) {
+	obj := sugartest.NewObject().
+		Set("Child", sugartest.NewObject()).
+		OnCall("Find", func(args ...interface{}) (interface{}, error) {
+			return (*ole.IDispatch)(nil), nil
+		})
+
+	sugar.Do(func(ctx sugar.Context) error {
+		o := ctx.FromDispatcher(obj)
+		if res := o.Call("Find"); !res.IsDispatch() || !res.IsNothing() {
+			t.Error("expected a null dispatch to be Nothing")
+		}
+		if res := o.Get("Child"); res.IsNothing() {
+			t.Error("expected an object not to be Nothing")
+		}
+		return nil
+	})
+}
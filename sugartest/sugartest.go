@@ -0,0 +1,206 @@
+//go:build windows
+
+// Package sugartest provides in-memory automation objects for testing code
+// built on sugar without a real COM server such as Excel.
+//
+// An Object is exposed through sugar.FromDispatcher (or Context.FromDispatcher)
+// and behaves like a COM object to every Chain operation:
+//
+//	book := sugartest.NewObject().Set("Name", "Book1")
+//	books := sugartest.NewCollection(book)
+//	app := sugartest.NewObject().Set("Workbooks", books)
+//
+//	sugar.Do(func(ctx sugar.Context) error {
+//		name, err := ctx.FromDispatcher(app).Get("Workbooks").Get("Item", 1).Get("Name").GetString()
+//		...
+//	})
+//
+// Values round-trip through VARIANTs, so they come back as sugar would return
+// them from COM: an int property, for example, reads back as int32.
+package sugartest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-ole/go-ole"
+	"github.com/xll-gen/sugar"
+)
+
+// Method implements a method, or a property that takes parameters.
+type Method func(args ...interface{}) (interface{}, error)
+
+// Object is an in-memory automation object backed by maps of properties and
+// methods. Member names are case insensitive. It is safe for concurrent use.
+type Object struct {
+	mu         sync.Mutex
+	props      map[string]interface{}
+	methods    map[string]Method
+	items      []interface{}
+	collection bool
+}
+
+// NewObject returns an object with no members.
+func NewObject() *Object {
+	return &Object{
+		props:   map[string]interface{}{},
+		methods: map[string]Method{},
+	}
+}
+
+// NewCollection returns a collection of items. Besides enumeration, it
+// provides Count and a 1-based Item that also accepts the Name of an item.
+func NewCollection(items ...interface{}) *Object {
+	o := NewObject()
+	o.collection = true
+	o.items = append(o.items, items...)
+	return o
+}
+
+// Set defines or replaces a property. Use "" for the default member.
+func (o *Object) Set(name string, value interface{}) *Object {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.props[strings.ToLower(name)] = value
+	return o
+}
+
+// Prop returns the current value of a property, for assertions after code
+// under test has written it.
+func (o *Object) Prop(name string) (interface{}, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	v, ok := o.props[strings.ToLower(name)]
+	return v, ok
+}
+
+// OnCall defines or replaces a method. It is also used for Get with
+// arguments, so it can implement parameterized properties.
+func (o *Object) OnCall(name string, fn Method) *Object {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.methods[strings.ToLower(name)] = fn
+	return o
+}
+
+// Add appends items to a collection.
+func (o *Object) Add(items ...interface{}) *Object {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.items = append(o.items, items...)
+	return o
+}
+
+// Get implements sugar.Dispatcher.
+func (o *Object) Get(name string, args ...interface{}) (interface{}, error) {
+	key := strings.ToLower(name)
+	o.mu.Lock()
+	fn, isMethod := o.methods[key]
+	v, isProp := o.props[key]
+	o.mu.Unlock()
+
+	switch {
+	case isMethod:
+		return fn(args...)
+	case isProp && len(args) == 0:
+		return v, nil
+	case o.collection && key == "count" && len(args) == 0:
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		return len(o.items), nil
+	case o.collection && (key == "item" || key == "") && len(args) == 1:
+		return o.item(args[0])
+	}
+	return nil, unknown(name)
+}
+
+// Put implements sugar.Dispatcher. Like a COM object, it only accepts
+// properties that exist.
+func (o *Object) Put(name string, args ...interface{}) error {
+	if len(args) != 1 {
+		return fmt.Errorf("sugartest: parameterized property %s cannot be set", name)
+	}
+	key := strings.ToLower(name)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.props[key]; !ok {
+		return unknown(name)
+	}
+	o.props[key] = args[0]
+	return nil
+}
+
+// Call implements sugar.Dispatcher. Calling a property without arguments
+// reads it, as COM allows.
+func (o *Object) Call(name string, args ...interface{}) (interface{}, error) {
+	return o.Get(name, args...)
+}
+
+// Enum implements sugar.Dispatcher.
+func (o *Object) Enum() ([]interface{}, error) {
+	if !o.collection {
+		return nil, errors.New("sugartest: object is not a collection")
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]interface{}(nil), o.items...), nil
+}
+
+func (o *Object) item(index interface{}) (interface{}, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	switch index := index.(type) {
+	case string:
+		for _, it := range o.items {
+			if obj, ok := it.(*Object); ok {
+				if name, ok := obj.Prop("Name"); ok && strings.EqualFold(fmt.Sprint(name), index) {
+					return it, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("sugartest: no item named %q: %w", index, errBadIndex)
+	default:
+		n, err := toInt(index)
+		if err != nil {
+			return nil, err
+		}
+		if n < 1 || n > len(o.items) {
+			return nil, fmt.Errorf("sugartest: index %d out of range [1, %d]: %w", n, len(o.items), errBadIndex)
+		}
+		return o.items[n-1], nil
+	}
+}
+
+func toInt(v interface{}) (int, error) {
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int8:
+		return int(n), nil
+	case int16:
+		return int(n), nil
+	case int32:
+		return int(n), nil
+	case int64:
+		return int(n), nil
+	case uint8:
+		return int(n), nil
+	case uint16:
+		return int(n), nil
+	case uint32:
+		return int(n), nil
+	case float64:
+		return int(n), nil
+	}
+	return 0, fmt.Errorf("sugartest: invalid index %v (%T)", v, v)
+}
+
+// errBadIndex is DISP_E_BADINDEX, which COM collections report for a missing
+// item.
+var errBadIndex = ole.NewError(0x8002000B)
+
+func unknown(name string) error {
+	return fmt.Errorf("%w: %s", sugar.ErrUnknownMember, name)
+}
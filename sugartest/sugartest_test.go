@@ -0,0 +1,122 @@
+//go:build windows
+
+package sugartest_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xll-gen/sugar"
+	"github.com/xll-gen/sugar/expression"
+	"github.com/xll-gen/sugar/sugartest"
+)
+
+func newApp() *sugartest.Object {
+	books := sugartest.NewCollection(
+		sugartest.NewObject().Set("Name", "Book1"),
+		sugartest.NewObject().Set("Name", "Book2"),
+	)
+	return sugartest.NewObject().
+		Set("Name", "Fake Excel").
+		Set("Visible", true).
+		Set("Workbooks", books).
+		OnCall("Calculate", func(args ...interface{}) (interface{}, error) {
+			return nil, errors.New("calculation failed")
+		})
+}
+
+func TestObject_GetPut(t *testing.T) {
+	fake := newApp()
+	sugar.Do(func(ctx sugar.Context) error {
+		app := ctx.FromDispatcher(fake)
+
+		if name, err := app.Get("name").GetString(); err != nil || name != "Fake Excel" {
+			t.Errorf("expected Fake Excel, got %q (%v)", name, err)
+		}
+		if err := app.Put("Visible", false).Err(); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		if v, _ := fake.Prop("Visible"); v != false {
+			t.Errorf("expected Visible to be false, got %v", v)
+		}
+		return nil
+	})
+}
+
+func TestObject_Errors(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := ctx.FromDispatcher(newApp())
+
+		err := app.Get("Missing").Get("Name").Err()
+		if code, ok := sugar.HRESULT(err); !ok || code != 0x80020003 {
+			t.Errorf("expected DISP_E_MEMBERNOTFOUND, got %v", err)
+		}
+
+		err = app.Call("Calculate").Err()
+		if err == nil || !strings.Contains(err.Error(), "calculation failed") {
+			t.Errorf("expected the method's error, got %v", err)
+		}
+		var comErr *sugar.ComError
+		if !errors.As(err, &comErr) || comErr.Op != sugar.OpCall || comErr.Member != "Calculate" {
+			t.Errorf("expected a ComError for Call Calculate, got %#v", err)
+		}
+		return nil
+	})
+}
+
+func TestObject_Collection(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		books := ctx.FromDispatcher(newApp()).Get("Workbooks")
+
+		var names []string
+		err := books.ForEach(func(item sugar.Chain) error {
+			name, err := item.Get("Name").GetString()
+			names = append(names, name)
+			return err
+		}).Err()
+		if err != nil {
+			t.Fatalf("ForEach failed: %v", err)
+		}
+		if strings.Join(names, ",") != "Book1,Book2" {
+			t.Errorf("expected Book1,Book2, got %v", names)
+		}
+
+		if n, err := books.Len(); err != nil || n != 2 {
+			t.Errorf("expected 2 items, got %d (%v)", n, err)
+		}
+		if name, err := books.Get("Item", "book2").Get("Name").GetString(); err != nil || name != "Book2" {
+			t.Errorf("expected Book2 by name, got %q (%v)", name, err)
+		}
+		return nil
+	})
+}
+
+func TestObject_Values2D(t *testing.T) {
+	cells := sugartest.NewObject().Set("Value", [][]interface{}{{1.0, "a"}, {2.0, "b"}})
+	sugar.Do(func(ctx sugar.Context) error {
+		grid, err := ctx.FromDispatcher(cells).Values2D()
+		if err != nil {
+			t.Fatalf("Values2D failed: %v", err)
+		}
+		if len(grid) != 2 || grid[1][1] != "b" {
+			t.Errorf("unexpected grid %v", grid)
+		}
+		return nil
+	})
+}
+
+func TestObject_Expression(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := ctx.FromDispatcher(newApp())
+
+		res, err := expression.Get(app, "Workbooks.Item(2).Name")
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if res != "Book2" {
+			t.Errorf("expected Book2, got %v", res)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,63 @@
+//go:build windows
+
+package sugar
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var procGetCurrentThreadId = syscall.NewLazyDLL("kernel32.dll").NewProc("GetCurrentThreadId")
+
+// ErrWrongThread is returned by CheckThread when a chain is used from an OS
+// thread other than the one it was created on.
+var ErrWrongThread = errors.New("chain used from a different OS thread")
+
+// CheckThreads makes every operation of the Context's chains call CheckThread
+// first and fail with its error, instead of risking RPC_E_WRONG_THREAD or a
+// crash deep inside COM. It costs a system call per operation. In a Do with
+// WithApartment(MTA) it has no effect, as any thread of the MTA may call its
+// objects.
+func CheckThreads() ContextOption {
+	return func(o *contextOptions) {
+		o.checkThreads = true
+	}
+}
+
+func currentThreadID() uint32 {
+	id, _, _ := procGetCurrentThreadId.Call()
+	return uint32(id)
+}
+
+// aptTypeMTA is the APTTYPE CoGetApartmentType reports for threads in the
+// multithreaded apartment, including the implicit one.
+const aptTypeMTA = 1
+
+// ownerThread returns the thread a new chain belongs to: the calling thread,
+// or 0 if it is in the MTA. MTA objects may be called from any thread of the
+// apartment, and an MTA Do leaves its goroutine free to migrate between them,
+// so their chains are not bound to a thread.
+func ownerThread() uint32 {
+	var aptType, qualifier int32
+	hr, _, _ := procCoGetApartmentType.Call(uintptr(unsafe.Pointer(&aptType)), uintptr(unsafe.Pointer(&qualifier)))
+	if hr == 0 && aptType == aptTypeMTA {
+		return 0
+	}
+	return currentThreadID()
+}
+
+// CheckThread reports whether the chain is used on the thread it was created
+// on. Chains created in the MTA are not bound to a thread and always pass.
+func (c *chain) CheckThread() error {
+	if c.thread == 0 {
+		return nil
+	}
+	if current := currentThreadID(); current != c.thread {
+		return fmt.Errorf("%w: created on thread %d, used on thread %d; COM objects belong to the apartment that created them, "+
+			"so marshal the object to the other thread (CoMarshalInterThreadInterfaceInStream or the Global Interface Table) "+
+			"or use it from the goroutine that created it", ErrWrongThread, c.thread, current)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+//go:build windows
+
+package sugar
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Tracer receives every COM invocation made by a Chain once it completes:
+// the operation kind (OpGet, OpCall, OpPut, ...), the member name, the
+// parameters as passed by the caller, the time the round trip took and its
+// error, if any. It is called on the thread that made the call.
+type Tracer func(op, member string, params []interface{}, elapsed time.Duration, err error)
+
+var tracer atomic.Pointer[Tracer]
+
+// SetTracer installs t as the process-wide tracer, replacing any previous
+// one. Pass nil to remove it; with no tracer installed, invocations are not
+// timed.
+//
+//	sugar.SetTracer(func(op, member string, params []interface{}, elapsed time.Duration, err error) {
+//		log.Printf("%s %s %v took %v (err: %v)", op, member, params, elapsed, err)
+//	})
+func SetTracer(t Tracer) {
+	if t == nil {
+		tracer.Store(nil)
+		return
+	}
+	tracer.Store(&t)
+}
+
+// traceStart returns a function that reports an invocation to the tracer, or
+// nil if there is no tracer.
+func traceStart(op, member string, params []interface{}) func(err error) {
+	t := tracer.Load()
+	if t == nil {
+		return nil
+	}
+	start := time.Now()
+	return func(err error) {
+		(*t)(op, member, params, time.Since(start), err)
+	}
+}
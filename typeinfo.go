@@ -0,0 +1,355 @@
+//go:build windows
+
+package sugar
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"github.com/go-ole/go-ole"
+)
+
+// Vtable indices of the IDispatch and ITypeInfo methods used to read type
+// information. go-ole's IDispatch.GetTypeInfo omits the iTInfo argument, so
+// the calls are made directly.
+const (
+	vtblDispGetTypeInfo = 4  // IDispatch
+	vtblGetFuncDesc     = 5  // ITypeInfo
+	vtblGetVarDesc      = 6  // ITypeInfo
+	vtblGetNames        = 7  // ITypeInfo
+	vtblReleaseTypeAttr = 19 // ITypeInfo
+	vtblReleaseFuncDesc = 20 // ITypeInfo
+	vtblReleaseVarDesc  = 21 // ITypeInfo
+)
+
+const (
+	invokeFunc        = 1
+	invokePropertyGet = 2
+	invokePropertyPut = 4
+	invokePropertyRef = 8
+
+	funcFlagRestricted = 0x1
+	varFlagReadOnly    = 0x1
+	varFlagRestricted  = 0x80
+)
+
+// typeDesc, elemDesc, funcDesc and varDesc mirror the Windows TYPEDESC,
+// ELEMDESC, FUNCDESC and VARDESC structures. They are only read through
+// pointers returned by ITypeInfo.
+type typeDesc struct {
+	lptdesc uintptr
+	vt      uint16
+}
+
+type elemDesc struct {
+	tdesc        typeDesc
+	pparamdescex uintptr
+	wParamFlags  uint16
+}
+
+type funcDesc struct {
+	memid             int32
+	lprgscode         uintptr
+	lprgelemdescParam uintptr
+	funckind          int32
+	invkind           int32
+	callconv          int32
+	cParams           int16
+	cParamsOpt        int16
+	oVft              int16
+	cScodes           int16
+	elemdescFunc      elemDesc
+	wFuncFlags        uint16
+}
+
+type varDesc struct {
+	memid       int32
+	lpstrSchema uintptr
+	oInst       uintptr
+	elemdescVar elemDesc
+	wVarFlags   uint16
+	varkind     int32
+}
+
+// typeMember is one entry of an object's dispatch interface. A property
+// with a getter and a setter appears once per accessor.
+type typeMember struct {
+	name       string
+	memid      int32
+	invkind    int32
+	params     int
+	optParams  int
+	vt         uint16
+	restricted bool
+	variable   bool
+	readOnly   bool
+}
+
+// typeMembers lists the members described by the type information of disp.
+func typeMembers(disp *ole.IDispatch) ([]typeMember, error) {
+	count, err := disp.GetTypeInfoCount()
+	if err != nil {
+		return nil, fmt.Errorf("object has no type information: %w", err)
+	}
+	if count == 0 {
+		return nil, errors.New("object has no type information")
+	}
+	var info *ole.ITypeInfo
+	if hr := comCall(unsafe.Pointer(disp), vtblDispGetTypeInfo, 0, uintptr(ole.GetUserDefaultLCID()), uintptr(unsafe.Pointer(&info))); hr != 0 {
+		return nil, fmt.Errorf("object has no type information: %w", ole.NewError(hr))
+	}
+	defer info.Release()
+	obj := unsafe.Pointer(info)
+
+	attr, err := info.GetTypeAttr()
+	if err != nil {
+		return nil, fmt.Errorf("reading type attributes: %w", err)
+	}
+	funcs, vars := int(attr.CFuncs), int(attr.CVars)
+	comCall(obj, vtblReleaseTypeAttr, uintptr(unsafe.Pointer(attr)))
+
+	members := make([]typeMember, 0, funcs+vars)
+	for i := 0; i < funcs; i++ {
+		var fd *funcDesc
+		if hr := comCall(obj, vtblGetFuncDesc, uintptr(i), uintptr(unsafe.Pointer(&fd))); hr != 0 {
+			return nil, fmt.Errorf("reading function %d: %w", i, ole.NewError(hr))
+		}
+		m := typeMember{
+			memid:      fd.memid,
+			invkind:    fd.invkind,
+			params:     int(fd.cParams),
+			optParams:  int(fd.cParamsOpt),
+			vt:         fd.elemdescFunc.tdesc.vt,
+			restricted: fd.wFuncFlags&funcFlagRestricted != 0,
+		}
+		comCall(obj, vtblReleaseFuncDesc, uintptr(unsafe.Pointer(fd)))
+		m.name = memberName(obj, m.memid)
+		members = append(members, m)
+	}
+	for i := 0; i < vars; i++ {
+		var vd *varDesc
+		if hr := comCall(obj, vtblGetVarDesc, uintptr(i), uintptr(unsafe.Pointer(&vd))); hr != 0 {
+			return nil, fmt.Errorf("reading variable %d: %w", i, ole.NewError(hr))
+		}
+		m := typeMember{
+			memid:      vd.memid,
+			invkind:    invokePropertyGet,
+			vt:         vd.elemdescVar.tdesc.vt,
+			restricted: vd.wVarFlags&varFlagRestricted != 0,
+			variable:   true,
+			readOnly:   vd.wVarFlags&varFlagReadOnly != 0,
+		}
+		comCall(obj, vtblReleaseVarDesc, uintptr(unsafe.Pointer(vd)))
+		m.name = memberName(obj, m.memid)
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+// memberName returns the name of memid, or "" if the type information does
+// not name it.
+func memberName(info unsafe.Pointer, memid int32) string {
+	var name *uint16
+	var n uint32
+	if hr := comCall(info, vtblGetNames, uintptr(memid), uintptr(unsafe.Pointer(&name)), 1, uintptr(unsafe.Pointer(&n))); hr != 0 || n == 0 {
+		return ""
+	}
+	defer ole.SysFreeString((*int16)(unsafe.Pointer(name)))
+	return ole.BstrToString(name)
+}
+
+// isObjectType reports whether vt declares an object rather than a value.
+func isObjectType(vt uint16) bool {
+	switch ole.VT(vt) {
+	case ole.VT_DISPATCH, ole.VT_UNKNOWN, ole.VT_PTR:
+		return true
+	}
+	return false
+}
+
+// MemberKind tells methods from properties in MemberInfo.
+type MemberKind int
+
+const (
+	// MemberMethod is a method, called with Call.
+	MemberMethod MemberKind = iota
+	// MemberProperty is a property, read with Get and written with Put or
+	// PutRef.
+	MemberProperty
+)
+
+// MemberInfo describes a member of an object's dispatch interface.
+type MemberInfo struct {
+	Name   string
+	Kind   MemberKind
+	DispID int32
+	// Params counts the parameters, including the optional ones. For a
+	// property it excludes the value being assigned.
+	Params         int
+	OptionalParams int
+	// Readable and Writable report the accessors of a property.
+	Readable bool
+	Writable bool
+}
+
+// Members lists the methods and properties described by the object's type
+// information, in declaration order. Restricted members, such as the
+// IUnknown and IDispatch methods, are left out.
+func (c *chain) Members() ([]MemberInfo, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.disp == nil {
+		return nil, errors.New("dispatch is nil")
+	}
+	members, err := typeMembers(c.disp)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []MemberInfo
+	props := make(map[int32]int)
+	for _, m := range members {
+		if m.restricted || m.name == "" {
+			continue
+		}
+		if m.invkind == invokeFunc {
+			infos = append(infos, MemberInfo{
+				Name:           m.name,
+				Kind:           MemberMethod,
+				DispID:         m.memid,
+				Params:         m.params,
+				OptionalParams: m.optParams,
+			})
+			continue
+		}
+		i, seen := props[m.memid]
+		if !seen {
+			i = len(infos)
+			props[m.memid] = i
+			infos = append(infos, MemberInfo{Name: m.name, Kind: MemberProperty, DispID: m.memid})
+		}
+		info := &infos[i]
+		switch m.invkind {
+		case invokePropertyGet:
+			info.Readable = true
+			info.Writable = info.Writable || (m.variable && !m.readOnly)
+			info.Params, info.OptionalParams = m.params, m.optParams
+		case invokePropertyPut, invokePropertyRef:
+			info.Writable = true
+			if !info.Readable && m.params > 0 {
+				info.Params, info.OptionalParams = m.params-1, m.optParams
+			}
+		}
+	}
+	return infos, nil
+}
+
+// Dump reads every scalar property described by the object's type
+// information. Object-typed and write-only properties, and properties that
+// take parameters, are skipped. If some properties cannot be read, Dump
+// returns the ones that could along with the joined errors.
+func (c *chain) Dump() (map[string]interface{}, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if err := c.preInvoke(OpGet, "Dump"); err != nil {
+		return nil, err
+	}
+	if c.disp == nil {
+		return nil, errors.New("dispatch is nil")
+	}
+	members, err := typeMembers(c.disp)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	var errs []error
+	for _, m := range members {
+		if m.invkind != invokePropertyGet || m.params != 0 || m.restricted || m.name == "" || isObjectType(m.vt) {
+			continue
+		}
+		if _, seen := values[m.name]; seen {
+			continue
+		}
+		result, err := c.invokeID(OpGet, m.name, m.memid, ole.DISPATCH_PROPERTYGET, nil)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// Variant-typed properties can still hold objects.
+		if result.VT != ole.VT_DISPATCH && result.VT != ole.VT_UNKNOWN {
+			values[m.name] = variantValue(result)
+		}
+		result.Clear()
+	}
+	return values, errors.Join(errs...)
+}
+
+// SuggestMembers makes failed Get, Call and Put report a similarly named
+// member of the object in ComError.Suggestion when the name is unknown. The
+// lookup reads the object's type information, so it only costs anything once
+// a call has already failed.
+func SuggestMembers() ContextOption {
+	return func(o *contextOptions) {
+		o.suggest = true
+	}
+}
+
+// suggestMember fills in the Suggestion of err if it reports an unknown
+// member and the Context asks for suggestions.
+func (c *chain) suggestMember(err error) error {
+	var comErr *ComError
+	if !c.options().suggest || !errors.As(err, &comErr) {
+		return err
+	}
+	if comErr.Code != dispEUnknownName && comErr.Code != dispEMemberNotFound {
+		return err
+	}
+	members, terr := typeMembers(c.disp)
+	if terr != nil {
+		return err
+	}
+	// Allow roughly one typo per three characters.
+	best, bestDist := "", len(comErr.Member)/3+2
+	for _, m := range members {
+		if m.restricted || m.name == "" {
+			continue
+		}
+		d := editDistance(strings.ToLower(comErr.Member), strings.ToLower(m.name))
+		if d == 0 {
+			// The member exists; it failed for another reason.
+			return err
+		}
+		if d < bestDist {
+			best, bestDist = m.name, d
+		}
+	}
+	comErr.Suggestion = best
+	return err
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(t)]
+}
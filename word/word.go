@@ -0,0 +1,214 @@
+//go:build windows
+
+// Package word provides typed wrappers over the Word.Application object
+// model, built on sugar.Chain in the same way as the excel package.
+package word
+
+import (
+	"github.com/xll-gen/sugar"
+)
+
+// WdSaveFormat is the file format passed to Document.SaveAs.
+type WdSaveFormat int
+
+const (
+	WdFormatDocument        WdSaveFormat = 0 // .doc
+	WdFormatText            WdSaveFormat = 2
+	WdFormatRTF             WdSaveFormat = 6
+	WdFormatHTML            WdSaveFormat = 8
+	WdFormatDocumentDefault WdSaveFormat = 16 // .docx
+	WdFormatPDF             WdSaveFormat = 17
+)
+
+// wdDoNotSaveChanges is the WdSaveOptions value that discards changes.
+const wdDoNotSaveChanges = 0
+
+// Application represents the Word.Application object.
+// It is the root of the Word object model.
+type Application interface {
+	sugar.Chain
+	// Documents returns the collection of all open documents.
+	Documents() Documents
+	// ActiveDocument returns the document that is currently active.
+	ActiveDocument() Document
+	// Selection returns the current selection or insertion point.
+	Selection() Selection
+	// Quit quits Word, discarding unsaved changes.
+	Quit() error
+}
+
+type application struct {
+	sugar.Chain
+}
+
+func (a *application) Documents() Documents {
+	return &documents{a.Get("Documents")}
+}
+
+func (a *application) ActiveDocument() Document {
+	return &document{a.Get("ActiveDocument")}
+}
+
+func (a *application) Selection() Selection {
+	return &selection{a.Get("Selection")}
+}
+
+func (a *application) Quit() error {
+	return a.Call("Quit", wdDoNotSaveChanges).Err()
+}
+
+// NewApplication creates a new Word instance.
+func NewApplication(ctx sugar.Context) Application {
+	return &application{ctx.Create("Word.Application")}
+}
+
+// GetApplication attaches to a running Word instance.
+func GetApplication(ctx sugar.Context) Application {
+	return &application{ctx.GetActive("Word.Application")}
+}
+
+// Documents represents the Documents collection.
+type Documents interface {
+	sugar.Chain
+	// Add creates a new empty document.
+	Add() Document
+	// Open opens the document at path.
+	Open(path string) Document
+	// Item returns a specific document by index or name.
+	Item(index interface{}) Document
+	// Count returns the number of open documents.
+	Count() (int, error)
+}
+
+type documents struct {
+	sugar.Chain
+}
+
+func (d *documents) Add() Document {
+	return &document{d.Call("Add")}
+}
+
+func (d *documents) Open(path string) Document {
+	return &document{d.Call("Open", path)}
+}
+
+func (d *documents) Item(index interface{}) Document {
+	return &document{d.Call("Item", index)}
+}
+
+func (d *documents) Count() (int, error) {
+	n, err := d.Get("Count").GetInt()
+	return int(n), err
+}
+
+// Document represents a Document object.
+type Document interface {
+	sugar.Chain
+	// Name returns the file name of the document.
+	Name() (string, error)
+	// Content returns a Range spanning the whole main story of the document.
+	Content() Range
+	// Range returns the Range between the given character positions.
+	Range(start, end int) Range
+	// Save saves the document.
+	Save() error
+	// SaveAs saves the document to path in the given format.
+	SaveAs(path string, format WdSaveFormat) error
+	// Close closes the document, discarding unsaved changes.
+	Close() error
+}
+
+type document struct {
+	sugar.Chain
+}
+
+func (d *document) Name() (string, error) {
+	return d.Get("Name").GetString()
+}
+
+func (d *document) Content() Range {
+	return &wordRange{d.Get("Content")}
+}
+
+func (d *document) Range(start, end int) Range {
+	return &wordRange{d.Call("Range", start, end)}
+}
+
+func (d *document) Save() error {
+	return d.Call("Save").Err()
+}
+
+func (d *document) SaveAs(path string, format WdSaveFormat) error {
+	// FileName, FileFormat.
+	return d.Call("SaveAs", path, int(format)).Err()
+}
+
+func (d *document) Close() error {
+	return d.Call("Close", wdDoNotSaveChanges).Err()
+}
+
+// Range represents a contiguous area of a document.
+type Range interface {
+	sugar.Chain
+	// Text returns the text of the range.
+	Text() (string, error)
+	// SetText replaces the text of the range.
+	SetText(text string) Range
+	// InsertBefore inserts text at the start of the range, extending it.
+	InsertBefore(text string) Range
+	// InsertAfter inserts text at the end of the range, extending it.
+	InsertAfter(text string) Range
+	// InsertParagraphAfter inserts a paragraph mark at the end of the range.
+	InsertParagraphAfter() Range
+}
+
+type wordRange struct {
+	sugar.Chain
+}
+
+func (r *wordRange) Text() (string, error) {
+	return r.Get("Text").GetString()
+}
+
+func (r *wordRange) SetText(text string) Range {
+	return &wordRange{r.Put("Text", text)}
+}
+
+func (r *wordRange) InsertBefore(text string) Range {
+	return &wordRange{r.Call("InsertBefore", text)}
+}
+
+func (r *wordRange) InsertAfter(text string) Range {
+	return &wordRange{r.Call("InsertAfter", text)}
+}
+
+func (r *wordRange) InsertParagraphAfter() Range {
+	return &wordRange{r.Call("InsertParagraphAfter")}
+}
+
+// Selection represents the current selection or insertion point.
+type Selection interface {
+	sugar.Chain
+	// TypeText inserts text at the selection, replacing it.
+	TypeText(text string) Selection
+	// TypeParagraph inserts a new paragraph at the selection.
+	TypeParagraph() Selection
+	// Range returns the Range covered by the selection.
+	Range() Range
+}
+
+type selection struct {
+	sugar.Chain
+}
+
+func (s *selection) TypeText(text string) Selection {
+	return &selection{s.Call("TypeText", text)}
+}
+
+func (s *selection) TypeParagraph() Selection {
+	return &selection{s.Call("TypeParagraph")}
+}
+
+func (s *selection) Range() Range {
+	return &wordRange{s.Get("Range")}
+}
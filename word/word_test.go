@@ -0,0 +1,67 @@
+//go:build windows
+
+package word_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xll-gen/sugar"
+	"github.com/xll-gen/sugar/word"
+)
+
+func TestWord_Package(t *testing.T) {
+	sugar.Do(func(ctx sugar.Context) error {
+		app := word.NewApplication(ctx)
+		if err := app.Err(); err != nil {
+			t.Skip("Word not installed:", err)
+			return nil
+		}
+		defer app.Quit()
+
+		doc := app.Documents().Add()
+		if err := doc.Err(); err != nil {
+			t.Fatalf("Documents.Add failed: %v", err)
+		}
+		if n, err := app.Documents().Count(); err != nil || n != 1 {
+			t.Errorf("expected 1 document, got %d (%v)", n, err)
+		}
+
+		app.Selection().TypeText("Hello").TypeParagraph()
+		if err := doc.Content().InsertAfter("Sugar").Err(); err != nil {
+			t.Fatalf("InsertAfter failed: %v", err)
+		}
+		text, err := doc.Content().Text()
+		if err != nil {
+			t.Fatalf("Text failed: %v", err)
+		}
+		if !strings.HasPrefix(text, "Hello\rSugar") {
+			t.Errorf("unexpected document text %q", text)
+		}
+		if got, _ := doc.Range(0, 5).Text(); got != "Hello" {
+			t.Errorf("expected Range(0, 5) to be Hello, got %q", got)
+		}
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "doc.docx")
+		if err := doc.SaveAs(path, word.WdFormatDocumentDefault); err != nil {
+			t.Fatalf("SaveAs failed: %v", err)
+		}
+		doc.Close()
+
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("expected %s to be written: %v", path, err)
+		}
+		opened := app.Documents().Open(path)
+		if err := opened.Err(); err != nil {
+			t.Fatalf("Documents.Open failed: %v", err)
+		}
+		defer opened.Close()
+		if name, err := opened.Name(); err != nil || name != "doc.docx" {
+			t.Errorf("expected doc.docx, got %q (%v)", name, err)
+		}
+		return nil
+	})
+}